@@ -3,106 +3,396 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"synclink/internal/config" // 导入配置包
+	"synclink/internal/link"
+	"synclink/internal/progress"
+	"synclink/internal/util"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
 // configCmd 代表 config 命令
 var configCmd = &cobra.Command{
-	Use:   "config <get|set> <属性> [新值]",
-	Short: "获取或设置 synclink 的配置项",
-	Long: `管理 synclink 的配置。
+	Use:   "config",
+	Short: "查看或修改 synclink 的设置",
+	// Long 由 init 根据登记的设置项生成
+}
+
+var configListOutput string
 
-你可以使用 'get' 来查看一个配置项的值，或者使用 'set' 来修改它。
+// configListCmd 代表 config list 命令
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有设置项及其当前值和来源",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(configListOutput)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return fmt.Errorf("加载配置文件失败: %w", err)
+		}
 
-支持的属性:
-  default_sync_path: 默认的同步目录路径
+		defs := config.SettingDefs()
+		records := make([]settingRecord, 0, len(defs))
+		for _, d := range defs {
+			value, source, err := cfg.GetSetting(d.Key)
+			if err != nil {
+				return err
+			}
+			records = append(records, settingRecord{
+				Key:         d.Key,
+				Value:       value,
+				Source:      string(source),
+				Type:        string(d.Type),
+				Values:      d.Values,
+				Default:     d.Default,
+				Env:         d.EnvVar(),
+				Description: d.Desc,
+			})
+		}
 
-示例:
-  synclink config get default_sync_path
-  synclink config set default_sync_path D:\MySyncFolder`,
-	Args: func(cmd *cobra.Command, args []string) error {
-		// 至少需要两个参数 (操作 和 属性)
-		if len(args) < 2 {
-			return errors.New("缺少参数。需要指定操作 (get/set) 和属性名称")
+		switch format {
+		case outputJSON:
+			return writeJSON(records)
+		case outputCSV:
+			rows := make([][]string, 0, len(records))
+			for _, r := range records {
+				rows = append(rows, []string{r.Key, r.Value, r.Source, r.Type, strings.Join(r.Values, ";"), r.Default, r.Env, r.Description})
+			}
+			return writeCSV([]string{"key", "value", "source", "type", "values", "default", "env", "description"}, rows)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{"设置项", "当前值", "来源", "类型"})
+		for i, r := range records {
+			table.Append([]string{r.Key, r.Value, settingSourceLabel(config.SettingSource(r.Source), r.Env), defs[i].TypeLabel()})
 		}
-		// 将操作转换为小写以方便比较
-		action := strings.ToLower(args[0])
-		if action != "get" && action != "set" {
-			return fmt.Errorf("无效的操作 '%s'。只支持 'get' 或 'set'", args[0])
+		table.Render()
+		fmt.Println("\n使用 'synclink config --help' 查看每个设置项的说明。")
+		return nil
+	},
+}
+
+// settingRecord 是 config list 在 json/csv 格式下输出的记录。
+type settingRecord struct {
+	Key         string   `json:"key"`
+	Value       string   `json:"value"`  // 当前生效的值，未设置且没有默认值时为空
+	Source      string   `json:"source"` // env、config、default 或 unset
+	Type        string   `json:"type"`
+	Values      []string `json:"values,omitempty"` // enum 类型的可选值
+	Default     string   `json:"default"`
+	Env         string   `json:"env"` // 覆盖该设置项的环境变量
+	Description string   `json:"description"`
+}
+
+// settingSourceLabel 返回设置项来源的显示文字。
+func settingSourceLabel(source config.SettingSource, env string) string {
+	switch source {
+	case config.SourceEnv:
+		return "环境变量 " + env
+	case config.SourceConfig:
+		return "config.json"
+	case config.SourceDefault:
+		return "默认值"
+	default:
+		return "未设置"
+	}
+}
+
+// configGetCmd 代表 config get 命令
+var configGetCmd = &cobra.Command{
+	Use:   "get <属性>",
+	Short: "查看一个设置项的当前值",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true // 参数个数已由 Args 检查，之后的错误 (例如无效的值) 不是用法错误
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return fmt.Errorf("加载配置文件失败: %w", err)
 		}
-		// 'set' 操作需要第三个参数 (新值)
-		if action == "set" && len(args) < 3 {
-			return errors.New("使用 'set' 操作时必须提供新值")
+		d, err := config.LookupSetting(args[0])
+		if err != nil {
+			return err
 		}
-		// 'get' 操作只需要两个参数
-		if action == "get" && len(args) > 2 {
-			return errors.New("使用 'get' 操作时不需要提供额外的值")
+		value, source, err := cfg.GetSetting(d.Key)
+		if err != nil {
+			return err
 		}
-		// 'set' 操作只需要三个参数
-		if action == "set" && len(args) > 3 {
-			return errors.New("使用 'set' 操作时提供了过多的参数")
+		fmt.Printf("%s: %s\n", d.Key, value)
+		if source == config.SourceEnv {
+			util.WarningPrint("(来自环境变量 %s)\n", d.EnvVar())
 		}
+		return nil
+	},
+}
 
-		// 检查属性名称是否有效 (目前只支持 default_sync_path)
-		// 将属性名也转为小写以方便比较
-		attributeName := strings.ToLower(args[1])
-		if attributeName != "default_sync_path" {
-			// 可以在这里扩展支持更多属性
-			return fmt.Errorf("不支持的配置属性: '%s'", args[1])
+// configSetCmd 代表 config set 命令
+var configSetCmd = &cobra.Command{
+	Use:   "set <属性> <新值>",
+	Short: "修改一个设置项",
+	Long: `校验并保存一个设置项。值会按设置项的类型检查并转换为规范形式，例如路径会被转换为绝对路径。
+
+使用 'synclink config --help' 查看所有设置项及其类型。
+
+示例:
+  synclink config set default_sync_path D:\MySyncFolder
+  synclink config set restore_on_conflict backup
+  synclink config set lock_timeout 2m`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true // 参数个数已由 Args 检查，之后的错误 (例如无效的值) 不是用法错误
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return fmt.Errorf("加载配置文件失败: %w", err)
+		}
+		d, err := config.LookupSetting(args[0])
+		if err != nil {
+			return err
 		}
 
-		return nil // 参数校验通过
+		var value string
+		err = link.UpdateConfig(fmt.Sprintf("将 %s 设置为 '%s'", d.Key, args[1]), func() error {
+			value, err = cfg.SetSetting(d.Key, args[1])
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("设置 %s 失败: %w", d.Key, err)
+		}
+		reportf("成功将 %s 设置为: %s\n", d.Key, value)
+		warnSettingOverridden(cfg, d)
+		return nil
 	},
+}
+
+// configUnsetCmd 代表 config unset 命令
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <属性>",
+	Short: "从 config.json 中删除一个设置项，恢复默认值",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// 加载或获取配置实例
+		cmd.SilenceUsage = true // 参数个数已由 Args 检查，之后的错误 (例如无效的值) 不是用法错误
 		cfg, err := config.GetConfig()
 		if err != nil {
 			return fmt.Errorf("加载配置文件失败: %w", err)
 		}
+		d, err := config.LookupSetting(args[0])
+		if err != nil {
+			return err
+		}
+		if _, ok, _ := cfg.StoredSetting(d.Key); !ok {
+			fmt.Printf("%s 未在 config.json 中设置，无需删除。\n", d.Key)
+			return nil
+		}
+
+		err = link.UpdateConfig(fmt.Sprintf("删除 %s 的设置", d.Key), func() error {
+			return cfg.UnsetSetting(d.Key)
+		})
+		if err != nil {
+			return fmt.Errorf("删除 %s 的设置失败: %w", d.Key, err)
+		}
+		if d.Default != "" {
+			reportf("已删除 %s 的设置，恢复为默认值: %s\n", d.Key, d.Default)
+		} else {
+			reportf("已删除 %s 的设置。\n", d.Key)
+		}
+		warnSettingOverridden(cfg, d)
+		return nil
+	},
+}
+
+// warnSettingOverridden 在设置项被环境变量覆盖时提醒用户，刚修改的值在环境变量存在期间不会生效。
+func warnSettingOverridden(cfg *config.Config, d config.SettingDef) {
+	if value, source, err := cfg.GetSetting(d.Key); err == nil && source == config.SourceEnv {
+		util.WarningPrint("注意: 环境变量 %s 当前将 %s 覆盖为 '%s'。\n", d.EnvVar(), d.Key, value)
+	}
+}
+
+// settingsHelp 根据登记的设置项生成帮助文本。
+func settingsHelp() string {
+	var b strings.Builder
+	for _, d := range config.SettingDefs() {
+		fmt.Fprintf(&b, "  %s (%s", d.Key, d.TypeLabel())
+		if d.Default != "" {
+			fmt.Fprintf(&b, "，默认 %s", d.Default)
+		}
+		fmt.Fprintf(&b, ")\n      %s\n      环境变量: %s\n", d.Desc, d.EnvVar())
+	}
+	return b.String()
+}
+
+// configRestoreBackupCmd 代表 config restore-backup 命令
+var configRestoreBackupCmd = &cobra.Command{
+	Use:   "restore-backup [序号|备份文件]",
+	Short: "从自动备份中恢复 config.json",
+	Long: `从自动备份中恢复 config.json。
+
+每个命令第一次修改 config.json 之前，synclink 都会把修改前的内容保存为同一目录下
+带时间戳的备份 (config.json.<时间>.bak)，并只保留最新的 config_backups 个 (默认为 5)。
+
+不带参数时列出所有备份并提示输入要恢复的序号；也可以直接指定序号或备份文件。
+恢复之前，当前的 config.json 也会被保存为备份，因此恢复操作本身可以撤销。
+config.json 损坏、无法加载时也可以使用此命令。
 
-		action := strings.ToLower(args[0])
-		attributeName := strings.ToLower(args[1]) // 保证与校验时一致
+示例:
+  synclink config restore-backup
+  synclink config restore-backup 2`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backups, err := config.ListBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Println("没有找到 config.json 的备份。")
+			return nil
+		}
 
-		switch action {
-		case "get":
-			switch attributeName {
-			case "default_sync_path":
-				// GetSettings() 返回的是结构体副本或实际值，可以直接访问
-				settings := cfg.GetSettings()
-				fmt.Printf("default_sync_path: %s\n", settings.DefaultSyncPath)
-			default:
-				// Arg 函数理论上应该已经阻止了这种情况
-				return fmt.Errorf("未知属性 '%s'", attributeName)
+		var chosen config.Backup
+		if len(args) == 1 {
+			chosen, err = findBackup(backups, args[0])
+			if err != nil {
+				return err
+			}
+		} else {
+			printBackups(backups)
+			answer, ok := readLine(fmt.Sprintf("输入要恢复的备份序号 (1-%d，直接回车取消): ", len(backups)))
+			if !ok || answer == "" {
+				fmt.Println("已取消。")
+				return nil
 			}
-		case "set":
-			newValue := args[2]
-			switch attributeName {
-			case "default_sync_path":
-				// 调用 SetDefaultSyncPath，它内部会处理保存逻辑
-				err := cfg.SetDefaultSyncPath(newValue)
-				if err != nil {
-					return fmt.Errorf("设置 default_sync_path 失败: %w", err)
-				}
-				fmt.Printf("成功将 default_sync_path 设置为: %s\n", newValue)
-			default:
-				// Arg 函数理论上应该已经阻止了这种情况
-				return fmt.Errorf("内部错误：遇到未知的属性 '%s'", attributeName)
+			chosen, err = findBackup(backups, answer)
+			if err != nil {
+				return err
 			}
-		default:
-			// Arg 函数理论上应该已经阻止了这种情况
-			return fmt.Errorf("内部错误：遇到未知的操作 '%s'", action)
+		}
+		if !chosen.Valid {
+			return fmt.Errorf("备份 '%s' 不是有效的配置文件，无法恢复", chosen.Path)
+		}
+
+		err = link.UpdateConfig(fmt.Sprintf("用备份 '%s' 覆盖 config.json", filepath.Base(chosen.Path)), func() error {
+			return config.RestoreBackup(chosen.Path)
+		})
+		if err != nil {
+			return fmt.Errorf("恢复配置备份失败: %w", err)
+		}
+		reportf("已从备份 '%s' 恢复 config.json (%d 个链接)。恢复之前的配置已另存为备份。\n", chosen.Path, chosen.Links)
+		return nil
+	},
+}
+
+var configMigrateCheck bool
+
+// configMigrateCmd 代表 config migrate 命令
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "将 config.json 升级到此 synclink 使用的版本",
+	Long: `将 config.json 升级到此 synclink 使用的结构版本。
+
+config.json 中记录了它的结构版本。每个命令加载配置时都会自动执行需要的迁移：
+先把原文件保存为备份 (见 'synclink config restore-backup')，再依次执行迁移步骤并写回。
+版本比此 synclink 更新的配置文件会被拒绝加载，以免保存时丢失它不认识的字段。
+
+此命令立即执行迁移。使用 --check 只列出需要执行的迁移步骤而不修改任何文件；
+需要迁移时以非零状态退出，便于在脚本中检查。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, steps, err := config.CheckMigration()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("配置文件版本: %s，此 synclink 使用的版本: %s\n", version, config.CurrentVersion)
+		if len(steps) == 0 {
+			fmt.Println("配置文件已是最新版本，无需迁移。")
+			return nil
+		}
+
+		fmt.Println("需要执行的迁移步骤:")
+		for i, s := range steps {
+			fmt.Printf("  %d. %s -> %s: %s\n", i+1, s.From, s.To, s.Desc)
+		}
+		if configMigrateCheck {
+			cmd.SilenceUsage = true
+			return errors.New("配置文件需要迁移，运行 'synclink config migrate' 执行")
 		}
 
-		return nil // 操作成功
+		err = link.UpdateConfig(fmt.Sprintf("将 config.json 从版本 %s 迁移到 %s", version, config.CurrentVersion), func() error {
+			_, err := config.Migrate()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("迁移配置文件失败: %w", err)
+		}
+		return nil
 	},
 }
 
+// printBackups 以表格形式列出配置备份，序号从 1 开始。
+func printBackups(backups []config.Backup) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"序号", "备份时间", "大小", "链接数", "文件"})
+	for i, b := range backups {
+		links := strconv.Itoa(b.Links)
+		if !b.Valid {
+			links = "(已损坏)"
+		}
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			b.Time.Format("2006-01-02 15:04:05"),
+			progress.FormatBytes(b.Size),
+			links,
+			filepath.Base(b.Path),
+		})
+	}
+	fmt.Println("config.json 的备份 (最新的在前):")
+	table.Render()
+}
+
+// findBackup 按序号 (从 1 开始) 或文件路径/名称查找备份。
+func findBackup(backups []config.Backup, arg string) (config.Backup, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(backups) {
+			return config.Backup{}, fmt.Errorf("无效的备份序号 %d，有效范围为 1-%d", n, len(backups))
+		}
+		return backups[n-1], nil
+	}
+	abs, _ := util.GetAbsPath(arg)
+	for _, b := range backups {
+		if b.Path == abs || filepath.Base(b.Path) == arg {
+			return b, nil
+		}
+	}
+	return config.Backup{}, fmt.Errorf("找不到备份 '%s'", arg)
+}
+
 // init 函数在程序启动时自动被调用
 func init() {
+	configCmd.Long = `查看或修改 synclink 的设置。设置保存在 config.json 中 (见 --config)。
+
+  synclink config list               列出所有设置项及其当前值和来源
+  synclink config get <属性>         查看一个设置项的当前值
+  synclink config set <属性> <新值>  校验并保存一个设置项
+  synclink config unset <属性>       删除设置，恢复默认值
+
+每个设置项都可以用对应的环境变量临时覆盖：环境变量优先于 config.json，只影响本次运行，不会被保存。
+
+支持的设置项:
+` + settingsHelp()
+
+	addOutputFlag(configListCmd, &configListOutput)
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd, configUnsetCmd)
+	configCmd.AddCommand(configRestoreBackupCmd)
+	configMigrateCmd.Flags().BoolVar(&configMigrateCheck, "check", false, "只列出需要执行的迁移步骤，不修改配置文件；需要迁移时以非零状态退出")
+	configCmd.AddCommand(configMigrateCmd)
 	// 将 configCmd 添加到 rootCmd
 	// 假设 rootCmd 在 cmd/root.go 中定义并导出
 	rootCmd.AddCommand(configCmd)
@@ -110,7 +400,6 @@ func init() {
 
 // 注意:
 // 1. 确保在 cmd/root.go (或其他地方) 定义了 rootCmd。
-// 2. 确保 internal/config/config.go 中的 GetConfig 和 SetDefaultSyncPath 函数按预期工作，
-//    特别是 SetDefaultSyncPath 应该包含调用 SaveConfig 的逻辑。
+// 2. 设置项登记在 internal/config/settings.go 中，SetSetting 和 UnsetSetting 负责校验和调用 SaveConfig。
 // 3. 错误处理返回 error，Cobra 会负责打印错误信息。
 // 4. 用户输出使用 fmt.Printf 直接打印到标准输出。
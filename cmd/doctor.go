@@ -0,0 +1,106 @@
+// cmd/doctor.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"synclink/internal/config"
+	"synclink/internal/link"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var doctorOutput string
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "检查所有已管理链接的健康状况 (只读)",
+	Long: `逐个检查配置中记录的链接，不做任何修改。
+
+对于符号链接，检查原始位置是否为符号链接、是否指向同步路径、同步数据是否存在，
+以及同步数据是否为预期的文件或文件夹；对于快捷方式，检查快捷方式文件及其目标是否存在。
+
+每个链接的状态为:
+  ok:           链接完好
+  broken:       符号链接或快捷方式文件丢失、无法读取，或本机无法解析链接 (例如未配置同步根目录)
+  drifted:      符号链接指向其他位置，或同步数据的类型与预期不符
+  missing-data: 同步数据 (或快捷方式的目标) 不存在
+  conflict:     原始位置被不是符号链接的其他数据占用
+
+存在任何状态不是 ok 的链接时以非零状态码退出，便于在登录脚本中发出提醒。
+broken 和 drifted 通常可以用 'synclink relink' 修复。
+
+使用 --output json 或 --output csv 时，输出与 'synclink list' 相同格式的记录 (仅包含本机记录的链接)，
+退出状态码的规则不变。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(doctorOutput)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		results := link.Diagnose(cfg)
+		if format != outputTable {
+			records := make([]linkRecord, 0, len(results))
+			checked := make(map[string]link.LinkHealth, len(results))
+			for _, h := range results {
+				checked[h.Name] = h
+			}
+			for _, ml := range link.ListManifestLinks(cfg) {
+				if h, ok := checked[ml.Name]; ok && ml.Local {
+					records = append(records, newLinkRecord(cfg, ml, h)) // 使用 Diagnose 的结果，不再重复检查
+				}
+			}
+			if err := writeLinkRecords(format, records); err != nil {
+				return err
+			}
+			return doctorResult(cmd, results)
+		}
+		if len(results) == 0 {
+			fmt.Println("当前没有管理的链接。")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"链接名称", "类型", "状态", "说明"})
+		for _, h := range results {
+			table.Append([]string{h.Name, linkTypeLabel(h.Info), string(h.Status), h.Detail})
+		}
+		table.Render()
+
+		if err := doctorResult(cmd, results); err != nil {
+			return err
+		}
+		fmt.Printf("\n全部 %d 个链接状态正常。\n", len(results))
+		return nil
+	},
+}
+
+// doctorResult 在存在状态异常的链接时返回错误，使命令以非零状态码退出。
+func doctorResult(cmd *cobra.Command, results []link.LinkHealth) error {
+	unhealthy := 0
+	for _, h := range results {
+		if h.Status != link.HealthOK {
+			unhealthy++
+		}
+	}
+	if unhealthy == 0 {
+		return nil
+	}
+	cmd.SilenceUsage = true // 检查结果不是用法错误
+	return fmt.Errorf("%d 个链接中有 %d 个状态异常", len(results), unhealthy)
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	addOutputFlag(doctorCmd, &doctorOutput)
+}
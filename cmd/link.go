@@ -15,6 +15,13 @@ var (
 	linkName       string
 	syncPath       string
 	createShortcut bool
+	absolutePath   bool
+	syncRootName   string
+	linkTags       []string
+	linkTree       bool
+	linkFiles      []string
+	linkInclude    []string
+	linkExclude    []string
 )
 
 // linkCmd represents the link command
@@ -24,11 +31,38 @@ var linkCmd = &cobra.Command{
 	Long: `将指定的 'target_path' (文件或文件夹) 移动到配置的同步目录下，
 并在原始位置创建一个指向新位置的符号链接。这有助于将配置文件等纳入同步范围。
 
+默认情况下，原始路径中的用户目录前缀会以环境变量令牌的形式保存
+(例如 %LOCALAPPDATA%\uv 或 $XDG_CONFIG_HOME/nvim)，使配置可以在其他机器或账户上使用。
+使用 --absolute 可以改为保存绝对路径。
+
+使用 --tag 可以为链接添加标签 (可重复使用或用逗号分隔)，之后可以在 list、relink、unlink 中
+用 --tag 按标签选择链接，或使用 'synclink tag' 修改标签。
+
+使用 --tree 可以逐个链接文件夹中的文件 (类似 GNU stow)：文件夹本身保持为真实目录，
+选中的每个文件被移动到同步目录中的包目录 <sync_path>/<link_name> 下相同的相对位置，
+并在原处创建指向它的符号链接。适用于缓存等本机数据与设置混在一起的目录。
+使用 --file 只链接指定的文件或子文件夹 (相对于目标路径，可重复使用)，默认链接全部文件。
+unlink 和 relink 会把记录的全部文件作为一个整体处理。
+
+使用 --exclude 和 --include 按通配符筛选要同步的文件 (隐含 --tree)：匹配排除规则的文件留在本机，
+有包含规则时只同步匹配的文件。不含 '/' 的规则 (例如 cache、*.log) 匹配任意一级的名称，
+含 '/' 的规则从文件夹根部开始匹配；匹配目录的规则同时匹配其下的全部文件。
+文件夹根部的 .synclinkignore 文件也会被读取：每行一个排除规则，以 ! 开头的行为包含规则，以 # 开头的行为注释。
+规则保存在配置中，relink 链接包目录中新出现的文件时同样遵循这些规则。
+
+使用 --root 可以将数据存放到命名同步根目录 (见 'synclink root') 下，
+此时同步路径以相对于根目录的形式保存，-s 表示根目录下的子目录。
+
 或者，使用 --shortcut 标志，可以在开始菜单中为 'target_path' 创建一个快捷方式。
+在 Linux 上，快捷方式是写入 $XDG_DATA_HOME/applications 的 .desktop 启动器。
 
 示例:
   synclink link C:\Users\CurrentUser\AppData\Roaming\MyApp\config.json
   synclink link D:\PortableApps\my-app -n MyPortableApp
+  synclink link %APPDATA%\Code\User -n vscode-user --tag editor
+  synclink link %LOCALAPPDATA%\uv --root dropbox -s configs
+  synclink link ~/.config/app --tree --file settings.json --file themes
+  synclink link %LOCALAPPDATA%\uv --exclude cache --exclude '*.lock'
   synclink link "C:\Program Files\MyTool\tool.exe" --shortcut
   synclink link "D:\Games\GameLauncher.exe" --shortcut -n MyGameLauncher`,
 	Args: cobra.ExactArgs(1), // 需要且仅需要一个参数: target_path
@@ -40,8 +74,15 @@ func init() {
 
 	// 定义命令行标志
 	linkCmd.Flags().StringVarP(&linkName, "name", "n", "", "指定链接的名称 (默认为目标路径的基本名称)")
-	linkCmd.Flags().StringVarP(&syncPath, "sync-path", "s", "", "指定同步目录的路径 (默认为配置中的 DefaultSyncPath)")
-	linkCmd.Flags().BoolVar(&createShortcut, "shortcut", false, "创建开始菜单快捷方式而不是符号链接")
+	linkCmd.Flags().StringVarP(&syncPath, "sync-path", "s", "", "指定同步目录的路径 (默认为配置中的 DefaultSyncPath)；与 --root 一起使用时为根目录下的相对路径")
+	linkCmd.Flags().StringVarP(&syncRootName, "root", "r", "", "将数据存放到指定的命名同步根目录下 (默认为配置中的 default_sync_root)")
+	linkCmd.Flags().BoolVar(&createShortcut, "shortcut", false, "创建开始菜单快捷方式 (Linux 上为 .desktop 启动器) 而不是符号链接")
+	linkCmd.Flags().BoolVar(&absolutePath, "absolute", false, "在配置中保存绝对路径，而不是带环境变量令牌的可移植路径")
+	linkCmd.Flags().StringSliceVarP(&linkTags, "tag", "t", nil, "为链接添加标签 (可重复使用或用逗号分隔)")
+	linkCmd.Flags().BoolVar(&linkTree, "tree", false, "逐个链接文件夹中的文件，文件夹本身保持为真实目录")
+	linkCmd.Flags().StringArrayVar(&linkFiles, "file", nil, "与 --tree 一起使用：只链接指定的文件或子文件夹 (相对于目标路径，可重复使用)")
+	linkCmd.Flags().StringArrayVar(&linkInclude, "include", nil, "只同步匹配该通配符的文件 (隐含 --tree，可重复使用)")
+	linkCmd.Flags().StringArrayVar(&linkExclude, "exclude", nil, "匹配该通配符的文件留在本机，不移动到同步目录 (隐含 --tree，可重复使用)")
 
 }
 
@@ -55,7 +96,7 @@ func runLinkCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	// 2. 验证和处理输入参数
-	targetPath, err = util.GetAbsPath(targetPath)
+	targetPath, err = util.GetAbsPath(util.ExpandPath(targetPath)) // 允许直接输入带令牌的路径
 	if err != nil {
 		return err
 	}
@@ -70,6 +111,23 @@ func runLinkCommand(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("目标路径 '%s' 不存在。", targetPath)
 	}
 
+	tags, err := config.NormalizeTags(linkTags)
+	if err != nil {
+		return err
+	}
+	if len(linkInclude) > 0 || len(linkExclude) > 0 {
+		linkTree = true // 只有逐个链接文件，应用才能继续看到一个完整的文件夹
+		if err := link.ValidatePatterns(append(append([]string(nil), linkInclude...), linkExclude...)); err != nil {
+			return err
+		}
+	}
+	if linkTree && createShortcut {
+		return fmt.Errorf("--tree 不能与 --shortcut 一起使用")
+	}
+	if len(linkFiles) > 0 && !linkTree {
+		return fmt.Errorf("--file 只能与 --tree 一起使用")
+	}
+
 	// 确定 linkName
 	if linkName == "" {
 		linkName = util.GetDefaultLinkName(targetPath)
@@ -79,16 +137,25 @@ func runLinkCommand(cmd *cobra.Command, args []string) error {
 	if _, exists := cfg.GetLink(linkName); exists {
 		return fmt.Errorf("链接名称 '%s' 已存在，请使用不同的名称，或先使用 'synclink unlink %s' 删除现有链接。", linkName, linkName)
 	}
-	// 确定 syncPathBase
-	syncPathBase := syncPath
-	if syncPathBase == "" {
-		settings := cfg.GetSettings()
-		if settings.DefaultSyncPath == "" {
-			return fmt.Errorf("未指定同步路径 (-s)，且配置中未设置默认同步路径，请使用 'synclink config set default_sync_path <路径>' 设置。")
+	// 确定 syncPathBase (快捷方式不需要同步目录)
+	var syncPathBase, syncRoot string
+	if !createShortcut {
+		syncPathBase, syncRoot, err = link.ResolveSyncBase(cfg, syncRootName, syncPath)
+		if err != nil {
+			return err
 		}
-		syncPathBase = settings.DefaultSyncPath
 	}
 
 	// 3. 执行核心逻辑
-	return link.CreateLinkOrShortcut(targetPath, linkName, syncPathBase, createShortcut)
+	opts := link.LinkOptions{
+		Shortcut:     createShortcut,
+		AbsolutePath: absolutePath,
+		SyncRoot:     syncRoot,
+		Tags:         tags,
+		Tree:         linkTree,
+		Files:        linkFiles,
+		Include:      linkInclude,
+		Exclude:      linkExclude,
+	}
+	return link.CreateLinkOrShortcut(targetPath, linkName, syncPathBase, opts)
 }
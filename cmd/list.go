@@ -4,88 +4,379 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path"
+	"slices"
+	"sort"
 	"strings"
 
 	"synclink/internal/config" // 确认你的 module path
+	"synclink/internal/link"
+	"synclink/internal/progress"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listExpand bool
+	listOutput string
+	listSort   string
+	listType   string
+	listName   string
+	listPath   string
+	listHealth []string
+	listTags   []string
+	listLong   bool
+)
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "列出所有 synclink 管理的链接",
-	Long:  `列出当前配置文件中记录的所有符号链接和快捷方式的详细信息。`,
+	Long: `列出同步目录清单中描述的所有链接，以及本机配置文件中记录的符号链接和快捷方式。
+
+每个同步根目录 (以及 default_sync_path) 中都有一份共享清单 (synclink.manifest.json)，
+随同步数据一起出现在每台机器上。"状态" 列显示每一项在本机上的情况:
+  已应用: 原始位置是指向同步数据的符号链接 (或快捷方式文件存在)
+  待应用: 本机尚未创建该链接，可以使用 'synclink restore' 或 'synclink relink' 应用
+  冲突:   原始位置被其他数据占用，或本机记录与清单不一致
+
+原始路径默认按配置中保存的形式显示 (可能包含 %LOCALAPPDATA%、$HOME 等环境变量令牌)，
+使用 --expand 显示在本机展开后的路径。相对于命名同步根目录保存的同步路径显示为 "[根目录名] 相对路径"，
+使用 --expand 时会解析为本机上的绝对路径。
+
+排序和筛选:
+  --sort name|type|created|path  排序方式 (默认 name；path 按本机上的原始路径排序)
+  --type symlink|tree|shortcut   只显示指定类型 (tree 为逐个文件链接的文件夹)
+  --name <通配符>                只显示名称匹配的链接，例如 --name 'vscode*'
+  --path <子串>                  只显示原始路径或同步路径包含该子串的链接 (不区分大小写)
+  --health <状态>                只显示健康状况 (见 'synclink doctor') 为指定值的链接，可以用逗号分隔多个
+  --tag <标签>                   只显示带有指定标签的链接 (见 'synclink tag')，可以用逗号分隔多个
+使用 --long 额外显示健康状况和同步数据的大小 (需要遍历同步数据，较慢)。
+
+使用 --output json 或 --output csv 输出便于脚本解析的结果。每条记录包含配置中保存的全部字段
+(name、shortcut、original_path、synced_path、sync_root、created_at、tags、mode、files、include、exclude，时间为 RFC 3339 格式)、
+本机解析后的路径 (resolved_original_path、resolved_synced_path)、local、manifest_dir、
+应用状态 state (applied/pending/conflicting) 以及实时检查的健康状况 health 和 health_detail
+(与 'synclink doctor' 相同)。使用 --long 时 size 为同步数据的字节数，否则 (以及快捷方式、同步数据不存在时) 为 null。
+这两种格式不受 --expand 影响。`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(listOutput)
+		if err != nil {
+			return err
+		}
+
 		// 1. 加载配置
 		cfg, err := config.GetConfig()
 		if err != nil {
 			return fmt.Errorf("加载配置失败: %w", err)
 		}
 
-		// 2. 获取链接信息
-		linksMap := cfg.GetLinks() // GetLinks 返回一个副本，不需要额外加锁
+		// 2. 合并共享清单与本机记录，然后筛选、排序
+		healths := newHealthCache(cfg)
+		links, err := filterLinks(cfg, link.ListManifestLinks(cfg), healths)
+		if err != nil {
+			return err
+		}
+		if err := sortLinks(cfg, links, listSort); err != nil {
+			return err
+		}
 
-		if len(linksMap) == 0 {
-			fmt.Println("当前没有管理的链接。")
+		if format != outputTable {
+			records := make([]linkRecord, 0, len(links))
+			for _, ml := range links {
+				r := newLinkRecord(cfg, ml, healths.check(ml))
+				if listLong {
+					if size, ok := syncedSize(cfg, ml.Info); ok {
+						r.Size = &size
+					}
+				}
+				records = append(records, r)
+			}
+			return writeLinkRecords(format, records)
+		}
+
+		filtered := listType != "" || listName != "" || listPath != "" || len(listHealth) > 0 || len(listTags) > 0
+		if len(links) == 0 {
+			if filtered {
+				fmt.Println("没有符合筛选条件的链接。")
+			} else {
+				fmt.Println("当前没有管理的链接。")
+			}
 			return nil
 		}
 
 		// 初始化 tablewriter
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"链接名称", "类型", "原始路径", "同步路径", "创建时间"})
-		// table.SetAutoWrapText(true)
-		// table.SetAutoFormatHeaders(true)
-		// table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-		// table.SetAlignment(tablewriter.ALIGN_LEFT)
-		// table.SetCenterSeparator("")
-		// table.SetColumnSeparator("")
-		// table.SetRowSeparator("")
-		// table.SetHeaderLine(true)
-		// table.SetBorder(true)
-		// table.SetTablePadding("  ") // 列间距
-		// table.SetNoWhiteSpace(true)
+		header := []string{"链接名称", "类型", "原始路径", "同步路径", "创建时间", "状态"}
+		showTags := false // 只有存在带标签的链接时才显示标签列
+		for _, ml := range links {
+			if len(ml.Info.Tags) > 0 {
+				showTags = true
+				break
+			}
+		}
+		if showTags {
+			header = append(header, "标签")
+		}
+		if listLong {
+			header = append(header, "健康", "大小")
+		}
+		table.SetHeader(header)
 
 		// 填充数据
-		for name := range linksMap {
-			info := linksMap[name]
-			var linkType, displayPath string
-
-			displayPath = info.SyncedPath
+		for _, ml := range links {
+			info := ml.Info
+			if listExpand {
+				if resolved, err := cfg.ResolveLink(info); err == nil {
+					info = resolved
+				} else {
+					info = info.Expanded() // 本机未配置同步根目录时仍显示相对路径
+				}
+			}
+			linkType := linkTypeLabel(info)
+			displayPath := info.SyncedPath
+			if info.SyncRoot != "" {
+				displayPath = fmt.Sprintf("[%s] %s", info.SyncRoot, info.SyncedPath)
+			}
 			if info.Shortcut {
-				linkType = "快捷方式"
 				if strings.Contains(info.SyncedPath, "Start Menu") {
 					displayPath = "开始菜单"
+				} else if strings.HasSuffix(info.SyncedPath, ".desktop") {
+					displayPath = "应用程序菜单"
 				}
-			} else {
-				linkType = "符号链接"
 			}
 
-			createdAtStr := info.CreatedAt.Format("2006-01-02 15:04:05")
-			table.Append([]string{
-				name,
+			createdAtStr := "" // 仅存在于清单中的链接在本机没有创建时间
+			if ml.Local {
+				createdAtStr = info.CreatedAt.Format("2006-01-02 15:04:05")
+			}
+			row := []string{
+				ml.Name,
 				linkType,
 				info.OriginalPath,
 				displayPath,
 				createdAtStr,
-			})
+				applyStateLabel(ml.State),
+			}
+			if showTags {
+				row = append(row, strings.Join(ml.Info.Tags, ", "))
+			}
+			if listLong {
+				sizeStr := "-"
+				if size, ok := syncedSize(cfg, ml.Info); ok {
+					sizeStr = progress.FormatBytes(size)
+				}
+				row = append(row, string(healths.check(ml).Status), sizeStr)
+			}
+			table.Append(row)
 		}
 
 		// 渲染表格
 		fmt.Println("\n当前管理的链接列表:")
 		table.Render()
-		fmt.Printf("\n总共管理 %d 个链接。\n", len(linksMap))
+		if filtered {
+			fmt.Printf("\n符合筛选条件的链接共 %d 个。\n", len(links))
+		} else {
+			fmt.Printf("\n总共管理 %d 个链接。\n", len(links))
+		}
 
 		return nil // 表示成功
 	},
 }
 
+// applyStateLabel 返回应用状态在表格中显示的文字。
+func applyStateLabel(state link.ApplyState) string {
+	switch state {
+	case link.StateApplied:
+		return "已应用"
+	case link.StatePending:
+		return "待应用"
+	case link.StateConflicting:
+		return "冲突"
+	default:
+		return string(state)
+	}
+}
+
+// linkTypeLabel 返回链接类型在表格中显示的文字。
+func linkTypeLabel(info config.LinkInfo) string {
+	switch {
+	case info.Shortcut:
+		return "快捷方式"
+	case info.IsTree():
+		return "符号链接 (tree)"
+	default:
+		return "符号链接"
+	}
+}
+
+// linkTypes 是 --type 支持的链接类型，顺序即 --sort type 的排序顺序。
+var linkTypes = []string{"symlink", "tree", "shortcut"}
+
+// linkTypeName 返回链接在 --type 中使用的类型名称。
+func linkTypeName(info config.LinkInfo) string {
+	switch {
+	case info.Shortcut:
+		return "shortcut"
+	case info.IsTree():
+		return "tree"
+	default:
+		return "symlink"
+	}
+}
+
+// healthKey 标识合并视图中的一项 (不同清单中可能有同名的链接)。
+type healthKey struct {
+	name, manifestDir string
+}
+
+// healthCache 保存 list 中每个链接的健康检查结果，筛选、表格和输出记录共用，每个链接只检查一次。
+type healthCache struct {
+	cfg     *config.Config
+	results map[healthKey]link.LinkHealth
+}
+
+func newHealthCache(cfg *config.Config) *healthCache {
+	return &healthCache{cfg: cfg, results: make(map[healthKey]link.LinkHealth)}
+}
+
+// check 返回链接的健康状况，第一次调用时实时检查。
+func (c *healthCache) check(ml link.ManifestLink) link.LinkHealth {
+	key := healthKey{ml.Name, ml.ManifestDir}
+	if h, ok := c.results[key]; ok {
+		return h
+	}
+	h := link.LinkHealth{Name: ml.Name, Info: ml.Info}
+	h.Status, h.Detail = link.CheckLink(c.cfg, ml.Info)
+	c.results[key] = h
+	return h
+}
+
+// filterLinks 按 --type、--name、--path、--tag 和 --health 筛选链接，保持原有顺序。
+func filterLinks(cfg *config.Config, links []link.ManifestLink, healths *healthCache) ([]link.ManifestLink, error) {
+	if listType != "" && !slices.Contains(linkTypes, listType) {
+		return nil, fmt.Errorf("无效的类型 '%s'。只支持 'symlink'、'tree' 或 'shortcut'", listType)
+	}
+	if listName != "" {
+		if _, err := path.Match(listName, ""); err != nil {
+			return nil, fmt.Errorf("无效的名称通配符 '%s': %w", listName, err)
+		}
+	}
+	healthWanted := make(map[link.Health]bool)
+	for _, h := range listHealth {
+		switch s := link.Health(strings.ToLower(strings.TrimSpace(h))); s {
+		case link.HealthOK, link.HealthBroken, link.HealthDrifted, link.HealthMissingData, link.HealthConflict:
+			healthWanted[s] = true
+		default:
+			return nil, fmt.Errorf("无效的健康状况 '%s'。只支持 ok、broken、drifted、missing-data 或 conflict", h)
+		}
+	}
+	pathNeedle := strings.ToLower(listPath)
+	tags, err := config.NormalizeTags(listTags)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []link.ManifestLink
+	for _, ml := range links {
+		if listType != "" && linkTypeName(ml.Info) != listType {
+			continue
+		}
+		if listName != "" {
+			if matched, _ := path.Match(listName, ml.Name); !matched {
+				continue
+			}
+		}
+		if pathNeedle != "" && !linkPathContains(cfg, ml.Info, pathNeedle) {
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(ml.Info, tags) {
+			continue
+		}
+		if len(healthWanted) > 0 {
+			if !healthWanted[healths.check(ml).Status] {
+				continue
+			}
+		}
+		result = append(result, ml)
+	}
+	return result, nil
+}
+
+// linkPathContains 判断链接的原始路径或同步路径 (保存的形式或本机解析后的形式) 是否包含 needle (已转为小写)。
+func linkPathContains(cfg *config.Config, info config.LinkInfo, needle string) bool {
+	paths := []string{info.OriginalPath, info.SyncedPath}
+	if resolved, err := cfg.ResolveLink(info); err == nil {
+		paths = append(paths, resolved.OriginalPath, resolved.SyncedPath)
+	}
+	for _, p := range paths {
+		if strings.Contains(strings.ToLower(p), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortLinks 按 --sort 对链接排序。输入已按名称排序，其他排序方式相同时保持名称顺序。
+func sortLinks(cfg *config.Config, links []link.ManifestLink, by string) error {
+	var less func(a, b link.ManifestLink) bool
+	switch strings.ToLower(by) {
+	case "", "name":
+		less = func(a, b link.ManifestLink) bool { return a.Name < b.Name }
+	case "type":
+		less = func(a, b link.ManifestLink) bool {
+			return slices.Index(linkTypes, linkTypeName(a.Info)) < slices.Index(linkTypes, linkTypeName(b.Info))
+		}
+	case "created":
+		// 仅存在于清单中的链接没有创建时间，排在最后
+		less = func(a, b link.ManifestLink) bool {
+			if a.Local != b.Local {
+				return a.Local
+			}
+			return a.Info.CreatedAt.Before(b.Info.CreatedAt)
+		}
+	case "path":
+		less = func(a, b link.ManifestLink) bool {
+			return strings.ToLower(originalPathForSort(cfg, a.Info)) < strings.ToLower(originalPathForSort(cfg, b.Info))
+		}
+	default:
+		return fmt.Errorf("无效的排序方式 '%s'。只支持 name、type、created 或 path", by)
+	}
+	sort.SliceStable(links, func(i, j int) bool { return less(links[i], links[j]) })
+	return nil
+}
+
+// originalPathForSort 返回用于排序的原始路径：本机上展开后的路径。
+func originalPathForSort(cfg *config.Config, info config.LinkInfo) string {
+	if resolved, err := cfg.ResolveLink(info); err == nil {
+		return resolved.OriginalPath
+	}
+	return info.Expanded().OriginalPath
+}
+
+// syncedSize 返回同步数据的字节数。快捷方式没有同步数据，无法统计 (例如同步数据不存在) 时 ok 为 false。
+func syncedSize(cfg *config.Config, info config.LinkInfo) (size int64, ok bool) {
+	if info.Shortcut {
+		return 0, false
+	}
+	size, err := link.SyncedDataSize(cfg, info)
+	return size, err == nil
+}
+
 func init() {
 	// 将 listCmd 添加到 rootCmd
 	// rootCmd 应该在 cmd/root.go 中定义
 	rootCmd.AddCommand(listCmd)
 
-	// 这里可以为 list 命令添加本地 flags (如果需要的话)
-	// listCmd.Flags().BoolP("details", "d", false, "显示更详细的信息")
+	listCmd.Flags().BoolVarP(&listExpand, "expand", "e", false, "显示展开环境变量令牌、解析同步根目录后的路径")
+	addOutputFlag(listCmd, &listOutput)
+	listCmd.Flags().StringVar(&listSort, "sort", "name", "排序方式: name、type、created 或 path")
+	listCmd.Flags().StringVar(&listType, "type", "", "只显示指定类型的链接: symlink、tree 或 shortcut")
+	listCmd.Flags().StringVar(&listName, "name", "", "只显示名称匹配该通配符的链接 (例如 'vscode*')")
+	listCmd.Flags().StringVar(&listPath, "path", "", "只显示原始路径或同步路径包含该子串的链接 (不区分大小写)")
+	listCmd.Flags().StringSliceVar(&listHealth, "health", nil, "只显示健康状况为指定值的链接: ok、broken、drifted、missing-data、conflict")
+	listCmd.Flags().StringSliceVarP(&listTags, "tag", "t", nil, "只显示带有指定标签的链接 (可重复使用或用逗号分隔)")
+	listCmd.Flags().BoolVarP(&listLong, "long", "l", false, "额外显示健康状况和同步数据的大小")
 }
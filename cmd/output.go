@@ -0,0 +1,152 @@
+// cmd/output.go
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"synclink/internal/config"
+	"synclink/internal/link"
+
+	"github.com/spf13/cobra"
+)
+
+// outputFormat 是报告类命令 (list、doctor、root list) 的输出格式。
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputCSV   outputFormat = "csv"
+)
+
+// addOutputFlag 为报告类命令注册 --output/-o 标志。
+func addOutputFlag(cmd *cobra.Command, p *string) {
+	cmd.Flags().StringVarP(p, "output", "o", string(outputTable), "输出格式: table、json 或 csv")
+}
+
+// parseOutputFormat 解析 --output 的值。
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch f := outputFormat(strings.ToLower(s)); f {
+	case outputTable, outputJSON, outputCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("无效的输出格式 '%s'。只支持 'table'、'json' 或 'csv'", s)
+	}
+}
+
+// linkRecord 是 list 和 doctor 在 json/csv 格式下输出的记录。字段名和含义保持稳定，供脚本使用：
+// 包含配置中保存的 LinkInfo 的全部字段 (路径为保存的原始形式)、在本机解析后的路径，
+// 以及实时检查得到的应用状态和健康状况。
+type linkRecord struct {
+	Name                 string     `json:"name"`
+	Shortcut             bool       `json:"shortcut"`
+	OriginalPath         string     `json:"original_path"`
+	SyncedPath           string     `json:"synced_path"`
+	SyncRoot             string     `json:"sync_root"`
+	CreatedAt            *time.Time `json:"created_at"`             // 仅存在于清单中的链接为 null
+	Tags                 []string   `json:"tags"`                   // 没有标签时为空数组
+	Mode                 string     `json:"mode"`                   // 链接模式：整个文件或文件夹为空，逐个文件链接为 tree
+	Files                []string   `json:"files"`                  // tree 模式管理的文件，其他链接为空数组
+	Include              []string   `json:"include"`                // tree 模式的包含规则
+	Exclude              []string   `json:"exclude"`                // tree 模式的排除规则
+	ResolvedOriginalPath string     `json:"resolved_original_path"` // 本机无法解析时为空
+	ResolvedSyncedPath   string     `json:"resolved_synced_path"`   // 本机无法解析时为空
+	Local                bool       `json:"local"`                  // 本机 config.json 中是否记录了该链接
+	ManifestDir          string     `json:"manifest_dir"`           // 所在清单的目录，仅存在于本机时为空
+	State                string     `json:"state"`                  // applied、pending 或 conflicting
+	Health               string     `json:"health"`                 // ok、broken、drifted、missing-data 或 conflict
+	HealthDetail         string     `json:"health_detail"`
+	Size                 *int64     `json:"size"` // 同步数据的字节数，仅 list --long 时统计，否则为 null
+}
+
+// linkRecordCSVHeader 是 csv 格式的表头，顺序与 csvRow 一致。
+var linkRecordCSVHeader = []string{
+	"name", "shortcut", "original_path", "synced_path", "sync_root", "created_at", "tags", "mode", "files", "include", "exclude",
+	"resolved_original_path", "resolved_synced_path", "local", "manifest_dir",
+	"state", "health", "health_detail", "size",
+}
+
+// newLinkRecord 根据 list 的合并视图和已检查的健康状况 h 构造输出记录。
+func newLinkRecord(cfg *config.Config, ml link.ManifestLink, h link.LinkHealth) linkRecord {
+	r := linkRecord{
+		Name:         ml.Name,
+		Shortcut:     ml.Info.Shortcut,
+		OriginalPath: ml.Info.OriginalPath,
+		SyncedPath:   ml.Info.SyncedPath,
+		SyncRoot:     ml.Info.SyncRoot,
+		Tags:         append([]string{}, ml.Info.Tags...),
+		Mode:         ml.Info.Mode,
+		Files:        append([]string{}, ml.Info.Files...),
+		Include:      append([]string{}, ml.Info.Include...),
+		Exclude:      append([]string{}, ml.Info.Exclude...),
+		Local:        ml.Local,
+		ManifestDir:  ml.ManifestDir,
+		State:        string(ml.State),
+	}
+	if ml.Local {
+		createdAt := ml.Info.CreatedAt
+		r.CreatedAt = &createdAt
+	}
+	if resolved, err := cfg.ResolveLink(ml.Info); err == nil {
+		r.ResolvedOriginalPath = resolved.OriginalPath
+		r.ResolvedSyncedPath = resolved.SyncedPath
+	}
+	r.Health, r.HealthDetail = string(h.Status), h.Detail
+	return r
+}
+
+func (r linkRecord) csvRow() []string {
+	createdAt, size := "", ""
+	if r.CreatedAt != nil {
+		createdAt = r.CreatedAt.Format(time.RFC3339Nano) // 与 JSON 中的格式一致
+	}
+	if r.Size != nil {
+		size = strconv.FormatInt(*r.Size, 10)
+	}
+	return []string{
+		r.Name, strconv.FormatBool(r.Shortcut), r.OriginalPath, r.SyncedPath, r.SyncRoot, createdAt, strings.Join(r.Tags, ";"),
+		r.Mode, strings.Join(r.Files, ";"), strings.Join(r.Include, ";"), strings.Join(r.Exclude, ";"),
+		r.ResolvedOriginalPath, r.ResolvedSyncedPath, strconv.FormatBool(r.Local), r.ManifestDir,
+		r.State, r.Health, r.HealthDetail, size,
+	}
+}
+
+// writeLinkRecords 以 json 或 csv 格式将记录写到标准输出。
+func writeLinkRecords(format outputFormat, records []linkRecord) error {
+	if format == outputJSON {
+		return writeJSON(records)
+	}
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		rows = append(rows, r.csvRow())
+	}
+	return writeCSV(linkRecordCSVHeader, rows)
+}
+
+// writeJSON 将 v 以缩进的 JSON 写到标准输出。
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("输出 JSON 失败: %w", err)
+	}
+	return nil
+}
+
+// writeCSV 将表头和各行以 CSV 写到标准输出。
+func writeCSV(header []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("输出 CSV 失败: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("输出 CSV 失败: %w", err)
+	}
+	return nil
+}
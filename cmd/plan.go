@@ -0,0 +1,180 @@
+// cmd/plan.go
+package cmd
+
+import (
+	"fmt"
+
+	"synclink/internal/config"
+	"synclink/internal/desired"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	desiredFile string
+	applyPrune  bool
+	applyYes    bool
+)
+
+const desiredFileHelp = `期望状态文件是一个 JSON 文件，列出本机应当存在的链接:
+
+  {
+    "links": [
+      { "name": "uv", "target": "%LOCALAPPDATA%\\uv", "root": "dropbox", "sync_path": "configs" },
+      { "name": "nvim", "target": "$XDG_CONFIG_HOME/nvim", "tags": ["editor"] },
+      { "name": "app", "target": "$HOME/.config/app", "mode": "tree", "exclude": ["cache"] },
+      { "name": "MyTool", "target": "C:\\Program Files\\MyTool\\tool.exe", "type": "shortcut" }
+    ]
+  }
+
+字段:
+  name:      链接名称
+  target:    原始位置 (符号链接) 或快捷方式的目标，可以包含环境变量令牌
+  root:      命名同步根目录 (可选，等同于 link --root)
+  sync_path: 同步目录，或根目录下的子路径 (可选，等同于 link -s)
+  type:      symlink (默认) 或 shortcut
+  mode:      tree 表示逐个文件链接 (可选，等同于 link --tree)
+  include:   tree 模式只同步匹配的文件 (可选，等同于 link --include，隐含 tree)
+  exclude:   tree 模式中匹配的文件留在本机 (可选，等同于 link --exclude，隐含 tree)
+  tags:      链接的标签 (可选；省略时不修改已有链接的标签)`
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan -f <file>",
+	Short: "比较期望状态文件与当前链接，显示需要执行的变更",
+	Long: `读取期望状态文件，将其与配置文件中记录的链接以及文件系统的实际状态进行比较，
+并显示 'synclink apply' 将要执行的变更。不会修改任何内容。
+
+变更标记:
+  +  创建 (移动数据并链接) 或恢复 (同步目录中已有数据)
+  ~  修复 (重新链接)、更新标签，或重新创建 (目标、同步位置、类型、模式或筛选规则发生变化)
+  -  移除 (仅在 apply --prune 时执行)
+  !  冲突，需要手动处理
+
+` + desiredFileHelp,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		changes, err := loadPlan()
+		if err != nil {
+			return err
+		}
+		printPlan(changes)
+		return nil
+	},
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply -f <file>",
+	Short: "按照期望状态文件创建、修复或移除链接",
+	Long: `读取期望状态文件并使本机收敛到该状态:
+创建缺失的链接，修复损坏的链接，重新创建与期望不一致的链接。
+
+配置文件中存在但期望状态文件中没有的链接只有在指定 --prune 时才会被移除
+(移除符号链接会把数据移回原始位置)。移除之前会列出这些链接并请求确认；使用 --yes 跳过确认。
+
+` + desiredFileHelp,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		changes, err := loadPlan()
+		if err != nil {
+			return err
+		}
+		printPlan(changes)
+
+		if removals := pendingRemovals(changes); applyPrune && len(removals) > 0 && !dryRun && !applyYes {
+			fmt.Printf("\n将移除以下 %d 个链接 (符号链接的数据会被移回原始位置):\n", len(removals))
+			for _, name := range removals {
+				fmt.Printf("  %s\n", name)
+			}
+			if !confirm("确认应用变更？") {
+				fmt.Println("已取消。")
+				return nil
+			}
+		}
+
+		fmt.Println("\n开始应用变更...")
+		failures := desired.Apply(changes, applyPrune)
+		if failures > 0 {
+			return fmt.Errorf("应用完成，但有 %d 项变更失败", failures)
+		}
+		reportf("应用完成。\n")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+
+	for _, c := range []*cobra.Command{planCmd, applyCmd} {
+		c.Flags().StringVarP(&desiredFile, "file", "f", "", "期望状态文件的路径")
+		_ = c.MarkFlagRequired("file")
+	}
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "移除配置中存在但期望状态文件中没有的链接")
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "与 --prune 一起使用时，不请求确认，直接移除链接")
+}
+
+// loadPlan 读取期望状态文件并计算变更列表。
+func loadPlan() ([]desired.Change, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	f, err := desired.Load(desiredFile)
+	if err != nil {
+		return nil, err
+	}
+	return desired.Plan(cfg, f), nil
+}
+
+// pendingRemovals 返回计划中要移除的链接名称。
+func pendingRemovals(changes []desired.Change) []string {
+	var names []string
+	for _, c := range changes {
+		if c.Action == desired.ActionRemove {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// printPlan 打印变更列表和汇总。
+func printPlan(changes []desired.Change) {
+	counts := make(map[desired.Action]int)
+	for _, c := range changes {
+		counts[c.Action]++
+		if c.Action == desired.ActionNone {
+			continue
+		}
+		fmt.Printf("%s %-8s %s", planMarker(c.Action), c.Action, c.Name)
+		if c.Reason != "" {
+			fmt.Printf(" (%s)", c.Reason)
+		}
+		fmt.Println()
+	}
+
+	pending := len(changes) - counts[desired.ActionNone]
+	if pending == 0 {
+		fmt.Println("当前状态已与期望状态一致，无需变更。")
+		return
+	}
+	fmt.Printf("\n计划: 创建 %d，恢复 %d，修复 %d，更新标签 %d，重新创建 %d，移除 %d，冲突 %d，无需变更 %d。\n",
+		counts[desired.ActionCreate], counts[desired.ActionRestore], counts[desired.ActionFix],
+		counts[desired.ActionRetag], counts[desired.ActionRecreate], counts[desired.ActionRemove],
+		counts[desired.ActionConflict], counts[desired.ActionNone])
+}
+
+// planMarker 返回变更类型在计划输出中的标记。
+func planMarker(a desired.Action) string {
+	switch a {
+	case desired.ActionCreate, desired.ActionRestore:
+		return "+"
+	case desired.ActionFix, desired.ActionRetag, desired.ActionRecreate:
+		return "~"
+	case desired.ActionRemove:
+		return "-"
+	default:
+		return "!"
+	}
+}
@@ -0,0 +1,105 @@
+// cmd/recover.go
+package cmd
+
+import (
+	"fmt"
+
+	"synclink/internal/journal"
+	"synclink/internal/link"
+	"synclink/internal/util"
+
+	"github.com/spf13/cobra"
+)
+
+// recoverCmd represents the recover command
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "完成或回滚因崩溃或断电而中断的操作",
+	Long: `link、unlink、restore、relink 等多步骤操作 (包括 tree 模式和快捷方式) 在执行前会写入操作日志
+(配置文件所在目录下的 journal/)，每完成一步都会更新日志，全部完成后删除。
+
+如果操作在中途被中断 (程序崩溃、断电、被强制结束)，日志条目会残留下来。
+recover 会逐个检查这些条目，并根据已完成的步骤和文件系统的实际状态:
+  - link:             数据已完整到达同步目录时补全符号链接和配置记录，否则回滚 (删除未完成的临时副本)
+  - unlink:           把数据移回原始位置并移除配置记录
+  - restore:          按原先的冲突策略完成恢复；同步数据不存在时把备份放回原位
+  - relink:           重新检查并创建符号链接
+  - link --tree:      逐个文件完成移动和链接 (尚未移动的文件继续移动)，然后补全配置记录
+  - unlink (tree):    删除剩余文件的符号链接，把它们移回原始位置，删除包目录中变空的目录并移除配置记录
+  - restore --tree:   按原先的冲突策略逐个文件完成恢复，然后补全配置记录；包目录中缺少文件时停止
+  - link --shortcut:  快捷方式文件已创建时补全配置记录，否则回滚
+  - unlink (快捷方式): 删除残留的快捷方式文件并移除配置记录
+
+跨设备移动时，日志会在完整的副本放到位之后记录 copied 步骤。只有记录了这一步，recover 才会删除源位置残留的数据；
+否则两处都有数据时无法确定目标位置的数据是否来自本次操作，需要手动检查。
+无法自动处理的条目会被保留，并说明需要手动检查的路径。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := link.PendingOperations()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("没有被中断的操作。")
+			return nil
+		}
+
+		fmt.Printf("发现 %d 个被中断的操作。\n", len(entries))
+		failures := 0
+		for _, e := range entries {
+			fmt.Printf("\n[%s] %s '%s' (开始于 %s，已完成步骤: %s)\n",
+				e.ID, describeOp(e.Op), e.LinkName, e.StartedAt.Format("2006-01-02 15:04:05"), e.Step)
+			if err := link.RecoverOperation(e); err != nil {
+				util.ErrorPrint("恢复失败: %v\n日志条目已保留: %s\n", err, e.Path())
+				failures++
+				continue
+			}
+			fmt.Println("已处理。")
+		}
+
+		if failures > 0 {
+			return fmt.Errorf("有 %d 个操作无法自动恢复，请根据上面的提示手动处理后再次运行 'synclink recover'", failures)
+		}
+		fmt.Println("\n所有被中断的操作均已处理。")
+		return nil
+	},
+}
+
+// describeOp 返回操作类型的中文描述。
+func describeOp(op journal.Op) string {
+	switch op {
+	case journal.OpCreate:
+		return "创建符号链接"
+	case journal.OpRemove:
+		return "移除符号链接"
+	case journal.OpRestore:
+		return "恢复符号链接"
+	case journal.OpRelink:
+		return "重新链接"
+	case journal.OpCreateShortcut:
+		return "创建快捷方式"
+	case journal.OpRemoveShortcut:
+		return "移除快捷方式"
+	case journal.OpCreateTree:
+		return "创建 tree 模式链接"
+	case journal.OpRemoveTree:
+		return "移除 tree 模式链接"
+	case journal.OpRestoreTree:
+		return "恢复 tree 模式链接"
+	default:
+		return string(op)
+	}
+}
+
+// warnPendingOperations 在执行其他命令前提示存在被中断的操作。
+func warnPendingOperations() {
+	entries, err := link.PendingOperations()
+	if err != nil || len(entries) == 0 {
+		return
+	}
+	util.WarningPrint("检测到 %d 个被中断的操作 (可能由崩溃或断电导致)，请运行 'synclink recover' 处理。\n", len(entries))
+}
+
+func init() {
+	rootCmd.AddCommand(recoverCmd)
+}
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"sync" // 用于并发处理 '*' 情况
+	"sync" // 用于并发处理多个链接
 
 	"synclink/internal/config"
 	"synclink/internal/link" // 导入链接处理逻辑
@@ -11,83 +11,97 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var relinkTags []string
+
 // relinkCmd represents the relink command
 var relinkCmd = &cobra.Command{
-	Use:   "relink <link_name>",
+	Use:   "relink [link_name|pattern]... [--tag <标签>]",
 	Short: "检查并重新链接已管理的符号链接或快捷方式",
-	Long: `检查指定名称（或使用 '*' 检查所有）的链接是否存在并且是预期的类型（符号链接或快捷方式）。
-如果链接丢失或不正确，则尝试根据存储的配置信息重新创建它。`,
-	Args: cobra.ExactArgs(1), // 需要正好一个参数: link_name 或 '*'
+	Long: `检查指定名称的链接是否存在并且是预期的类型（符号链接或快捷方式）。
+如果链接丢失或不正确，则尝试根据存储的配置信息重新创建它。
+
+可以同时指定多个名称，也可以使用 shell 风格的通配符 (*、?、[...]) 匹配配置中的链接名称，
+例如 'vscode-*'；'*' 会检查所有链接。通配符需要加引号，避免被 shell 展开。
+使用 --tag 只选择带有指定标签的链接 (多个标签之间为 "或" 的关系)；只指定 --tag 时从所有链接中选择。
+relink 不会移动或删除数据，因此不需要确认。
+
+示例:
+  synclink relink uv
+  synclink relink nvim wezterm
+  synclink relink 'vscode-*'
+  synclink relink --tag editor
+  synclink relink '*'`,
+	Args: linkSelectionArgs(&relinkTags), // 至少需要一个链接名称、通配符或标签
 	RunE: runRelink,
 }
 
 func init() {
 	rootCmd.AddCommand(relinkCmd)
+
+	relinkCmd.Flags().StringSliceVarP(&relinkTags, "tag", "t", nil, "只选择带有指定标签的链接 (可重复使用或用逗号分隔)")
 }
 
 func runRelink(cmd *cobra.Command, args []string) error {
-	linkName := args[0]
-
 	cfg, err := config.GetConfig() // 加载或获取配置
 	if err != nil {
 		return err
 	}
 
-	if linkName == "*" {
-		return relinkAllLinks(cfg)
-	} else {
-		return relinkSingleLink(cfg, linkName)
+	names, usedGlob, err := selectLinks(cfg, args, relinkTags)
+	if err != nil {
+		return err
 	}
-}
 
-// relinkSingleLink 处理单个链接的重新链接逻辑
-func relinkSingleLink(cfg *config.Config, name string) error {
-	_, exists := cfg.GetLink(name)
-	if !exists {
-		return fmt.Errorf("链接 '%s' 未被 synclink 管理。", name)
+	if len(names) == 1 && !usedGlob {
+		return relinkSingleLink(names[0])
 	}
+	if err := relinkLinks(names); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+	return nil
+}
 
+// relinkSingleLink 处理单个链接的重新链接逻辑
+func relinkSingleLink(name string) error {
 	fmt.Printf("正在检查链接 '%s'...\n", name)
 	err := link.RelinkLinkOrShortcut(name) // 内部会再次加载配置获取详细信息
 	if err != nil {
 		return fmt.Errorf("尝试重新链接 '%s' 时出错: %v", name, err)
 	} else {
-		fmt.Printf("链接 '%s' 检查完毕，状态正常或已成功重新链接。\n", name)
+		reportf("链接 '%s' 检查完毕，状态正常或已成功重新链接。\n", name)
 		return nil
 	}
 }
 
-// relinkAllLinks 处理重新链接所有已管理链接的逻辑
-func relinkAllLinks(cfg *config.Config) error {
-	links := cfg.GetLinks() // 获取所有链接的映射副本
-
-	if len(links) == 0 {
+// relinkLinks 处理重新链接多个已管理链接的逻辑
+func relinkLinks(names []string) error {
+	if len(names) == 0 {
 		fmt.Println("没有已管理的链接可供重新链接。")
 		return nil
 	}
 
-	fmt.Printf("开始检查并重新链接所有 %d 个已管理的链接...\n", len(links))
+	fmt.Printf("开始检查并重新链接 %d 个已管理的链接...\n", len(names))
 
 	var wg sync.WaitGroup
 	var successCount int
 	var failureCount int
-	var lastErr error
 	var mu sync.Mutex // 用于保护计数器
 
-	for name := range links {
+	for _, name := range names {
 		wg.Add(1)
 		go func(linkNameToRelink string) { // 使用 go routine 并发处理 (可选)
 			defer wg.Done()
 
 			fmt.Printf("[+] 正在处理链接 '%s'...\n", linkNameToRelink)
-			lastErr = link.RelinkLinkOrShortcut(linkNameToRelink)
+			err := link.RelinkLinkOrShortcut(linkNameToRelink)
 
 			mu.Lock() // 锁定以更新计数器
-			if lastErr != nil {
-				util.ErrorPrint("[-] 重新链接 '%s' 失败: %v\n", linkNameToRelink, lastErr)
+			if err != nil {
+				util.ErrorPrint("[-] 重新链接 '%s' 失败: %v\n", linkNameToRelink, err)
 				failureCount++
 			} else {
-				fmt.Printf("[-] 链接 '%s' 重新链接成功或状态正常。\n", linkNameToRelink)
+				reportf("[-] 链接 '%s' 重新链接成功或状态正常。\n", linkNameToRelink)
 				successCount++
 			}
 			mu.Unlock() // 解锁
@@ -97,10 +111,13 @@ func relinkAllLinks(cfg *config.Config) error {
 
 	wg.Wait() // 等待所有 goroutine 完成
 
-	fmt.Println("\n重新链接操作完成。")
-	fmt.Printf("总计：%d 个链接\n", len(links))
-	fmt.Printf("成功：%d 个\n", successCount)
-	fmt.Printf("失败：%d 个\n", failureCount)
+	reportf("\n重新链接操作完成。\n")
+	reportf("总计：%d 个链接\n", len(names))
+	reportf("成功：%d 个\n", successCount)
+	reportf("失败：%d 个\n", failureCount)
 
+	if failureCount > 0 {
+		return fmt.Errorf("有 %d 个链接重新链接失败", failureCount)
+	}
 	return nil
 }
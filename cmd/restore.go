@@ -0,0 +1,105 @@
+// cmd/restore.go
+package cmd
+
+import (
+	"synclink/internal/config"
+	"synclink/internal/link"
+	"synclink/internal/util"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreName       string
+	restoreSyncPath   string
+	restoreOnConflict string
+	restoreAbsolute   bool
+	restoreRoot       string
+	restoreTree       bool
+	restoreInclude    []string
+	restoreExclude    []string
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <target_path>",
+	Short: "使用同步目录中已有的数据在原始位置恢复符号链接",
+	Long: `在新机器上恢复链接：同步目录 (例如 Dropbox) 中已经有数据，
+而本机的原始位置为空或只有默认配置时使用。
+
+synclink 会在同步目录中查找名为 link_name 的同步项 (文件夹位于 <sync_path>/<link_name>，
+文件位于 <sync_path>/files/<link_name>)，然后在 'target_path' 创建指向它的符号链接。
+不会移动任何数据到同步目录。
+
+使用 --tree 恢复 tree 模式 (见 'synclink link --tree') 的链接：包目录 <sync_path>/<link_name>
+中的每个文件都会在 'target_path' 下相同的相对位置创建符号链接，缺少的父目录会被创建，
+'target_path' 保持为真实目录。--on-conflict 逐个文件生效。
+--include、--exclude (隐含 --tree) 和包目录中 .synclinkignore 的规则与 'synclink link' 相同，
+被排除的文件不会被链接。
+
+如果 'target_path' 上已有本地副本，由 --on-conflict 决定如何处理:
+  backup:  将本地副本重命名为 <target_path>.synclink-backup-<时间戳>
+  discard: 删除本地副本
+  abort:   放弃恢复 (默认，可以通过 'synclink config set restore_on_conflict' 修改)
+
+示例:
+  synclink restore C:\Users\CurrentUser\AppData\Local\uv
+  synclink restore ~/.config/nvim -n nvim --on-conflict backup
+  synclink restore ~/.config/app --tree`,
+	Args: cobra.ExactArgs(1), // 需要且仅需要一个参数: target_path
+	RunE: runRestoreCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().StringVarP(&restoreName, "name", "n", "", "同步项的链接名称 (默认为目标路径的基本名称)")
+	restoreCmd.Flags().StringVarP(&restoreSyncPath, "sync-path", "s", "", "指定同步目录的路径 (默认为配置中的 DefaultSyncPath)；与 --root 一起使用时为根目录下的相对路径")
+	restoreCmd.Flags().StringVarP(&restoreRoot, "root", "r", "", "在指定的命名同步根目录下查找同步项 (默认为配置中的 default_sync_root)")
+	restoreCmd.Flags().StringVar(&restoreOnConflict, "on-conflict", "", "原始位置已有本地副本时的处理策略: backup、discard 或 abort (默认为配置中的 restore_on_conflict，未设置时为 abort)")
+	restoreCmd.Flags().BoolVar(&restoreAbsolute, "absolute", false, "在配置中保存绝对路径，而不是带环境变量令牌的可移植路径")
+	restoreCmd.Flags().BoolVar(&restoreTree, "tree", false, "逐个文件恢复 tree 模式的链接")
+	restoreCmd.Flags().StringArrayVar(&restoreInclude, "include", nil, "只链接匹配该通配符的文件 (隐含 --tree，可重复使用)")
+	restoreCmd.Flags().StringArrayVar(&restoreExclude, "exclude", nil, "不链接匹配该通配符的文件 (隐含 --tree，可重复使用)")
+}
+
+func runRestoreCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	onConflict := restoreOnConflict
+	if !cmd.Flags().Changed("on-conflict") {
+		onConflict = cfg.GetSettings().RestoreConflictPolicy()
+	}
+	policy, err := link.ParseConflictPolicy(onConflict)
+	if err != nil {
+		return err
+	}
+
+	targetPath, err := util.GetAbsPath(util.ExpandPath(args[0]))
+	if err != nil {
+		return err
+	}
+
+	name := restoreName
+	if name == "" {
+		name = util.GetDefaultLinkName(targetPath)
+	}
+
+	syncPathBase, syncRoot, err := link.ResolveSyncBase(cfg, restoreRoot, restoreSyncPath)
+	if err != nil {
+		return err
+	}
+
+	opts := link.LinkOptions{
+		AbsolutePath: restoreAbsolute,
+		SyncRoot:     syncRoot,
+	}
+	if restoreTree || len(restoreInclude) > 0 || len(restoreExclude) > 0 {
+		opts.Include, opts.Exclude = restoreInclude, restoreExclude
+		return link.RestoreTreeLink(targetPath, name, syncPathBase, policy, opts)
+	}
+	return link.RestoreSymbolicLink(targetPath, name, syncPathBase, policy, opts)
+}
@@ -8,9 +8,16 @@ import (
 	"github.com/spf13/cobra"
 
 	"synclink/internal/config" // 引入内部配置包
+	"synclink/internal/link"
 	"synclink/internal/util"
 )
 
+// dryRun 对应全局的 --dry-run 标志
+var dryRun bool
+
+// configFile 对应全局的 --config 标志
+var configFile string
+
 // rootCmd 代表没有调用子命令时的基础命令
 var rootCmd = &cobra.Command{
 	Use:   "synclink",
@@ -26,20 +33,78 @@ var rootCmd = &cobra.Command{
 	// PersistentPreRunE 会在任何子命令执行 *之前* 运行。
 	// 这是加载配置的理想位置，确保所有子命令都能访问到配置。
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// 尝试加载应用程序配置
-		_, err := config.LoadConfig()
-		if err != nil {
-			// 如果加载配置失败，则向用户报告错误并阻止命令继续执行
-			// 使用 fmt.Errorf 包装原始错误以提供更多上下文
-			return fmt.Errorf("加载配置文件失败: %w", err)
+		util.SetConfigPath(configFile)
+		// 在加载配置之前开启 dry-run，使加载时的自动迁移也只在内存中进行
+		if dryRun {
+			if cmd == recoverCmd {
+				return fmt.Errorf("recover 不支持 --dry-run：被中断的操作需要根据文件系统的实际状态逐步处理")
+			}
+			link.SetDryRun(true)
+			config.SetDryRun(true)
+		}
+		// 尝试加载应用程序配置。restore-backup 用来修复无法加载的配置文件，migrate 需要在迁移之前检查原始文件，
+		// 它们都不需要加载
+		if cmd != configRestoreBackupCmd && cmd != configMigrateCmd {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				// 如果加载配置失败，则向用户报告错误并阻止命令继续执行
+				// 使用 fmt.Errorf 包装原始错误以提供更多上下文
+				return fmt.Errorf("加载配置文件失败: %w", err)
+			}
+			util.SetVerifyCopies(cfg.GetSettings().VerifyCopies())
+		}
+		if cmd != recoverCmd {
+			warnPendingOperations()
 		}
 		// 配置加载成功，可以继续执行子命令
 		return nil
 	},
+	// PersistentPostRun 在子命令成功执行 *之后* 运行。
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if dryRun {
+			printDryRunPlan()
+		}
+	},
 	// 如果用户只输入 "synclink" 而没有子命令，默认行为是显示帮助信息。
 	// Cobra 默认就是这样，所以不需要显式设置 Run。
 }
 
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "只打印将要执行的操作 (移动、符号链接、删除、配置更新)，不做任何更改")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "使用指定的配置文件 (默认使用环境变量 "+util.ConfigPathEnv+" 指定的文件，或用户配置目录下的 synclink/config.json)")
+}
+
+// printDryRunPlan 按链接分组打印 --dry-run 模式下记录的操作。
+func printDryRunPlan() {
+	actions := link.PlannedActions()
+	if len(actions) == 0 {
+		fmt.Println("\n[dry-run] 无需执行任何操作。")
+		return
+	}
+
+	fmt.Println("\n[dry-run] 未做任何更改。实际执行时将依次进行以下操作:")
+	group, n := "", 0
+	for i, a := range actions {
+		if i == 0 || a.Link != group {
+			group, n = a.Link, 0
+			if group == "" {
+				fmt.Println("配置:")
+			} else {
+				fmt.Printf("'%s':\n", group)
+			}
+		}
+		n++
+		fmt.Printf("  %d. %s\n", n, a)
+	}
+}
+
+// reportf 打印命令的执行结果。--dry-run 模式下什么都没有执行，结果由计划代替，因此不打印。
+func reportf(format string, a ...any) {
+	if !dryRun {
+		fmt.Printf(format, a...)
+	}
+}
+
 // Execute 将所有子命令添加到根命令中，并适当设置标志。
 // 这是 main.main() 调用的主要函数。
 func Execute() {
@@ -48,7 +113,7 @@ func Execute() {
 	// rootCmd.Execute() 会解析命令行参数，找到匹配的子命令并执行
 	err := rootCmd.Execute()
 	if err != nil {
-		util.ErrorPrint(err.Error())
+		util.ErrorPrint("%s\n", err.Error())
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,132 @@
+// cmd/select.go
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"synclink/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// isGlobPattern 判断参数是否包含通配符 (*、? 或 [)。
+func isGlobPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// linkSelectionArgs 返回 unlink、relink 的参数校验函数：至少需要一个链接名称或通配符，
+// 除非通过 --tag 按标签选择。
+func linkSelectionArgs(tags *[]string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(*tags) == 0 {
+			return fmt.Errorf("需要指定至少一个链接名称或通配符，或使用 --tag 按标签选择")
+		}
+		return nil
+	}
+}
+
+// selectLinks 将命令行参数 (链接名称或 shell 风格的通配符，例如 'vscode-*') 解析为配置中的链接名称。
+// 结果已去重并排序。不含通配符的名称必须存在；通配符至少要匹配一个链接。
+// tags 非空时只保留带有其中任一标签的链接；此时没有参数表示从所有链接中选择。
+// 任何参数含有通配符或使用了标签时 usedGlob 为 true。
+func selectLinks(cfg *config.Config, args, tags []string) (names []string, usedGlob bool, err error) {
+	tags, err = config.NormalizeTags(tags)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(tags) > 0 {
+		usedGlob = true
+		if len(args) == 0 {
+			args = []string{"*"}
+		}
+	}
+
+	links := cfg.GetLinks()
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		if !isGlobPattern(arg) {
+			if _, ok := links[arg]; !ok {
+				return nil, false, fmt.Errorf("未在配置中找到名为 '%s' 的链接或快捷方式", arg)
+			}
+			if !seen[arg] {
+				seen[arg] = true
+				names = append(names, arg)
+			}
+			continue
+		}
+
+		usedGlob = true
+		if _, err := path.Match(arg, ""); err != nil {
+			return nil, false, fmt.Errorf("无效的通配符 '%s': %w", arg, err)
+		}
+		matched := false
+		for name := range links {
+			if ok, _ := path.Match(arg, name); ok {
+				matched = true
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+		if !matched && len(links) > 0 {
+			return nil, false, fmt.Errorf("没有链接匹配 '%s'", arg)
+		}
+	}
+
+	if len(tags) > 0 {
+		var tagged []string
+		for _, name := range names {
+			if hasAnyTag(links[name], tags) {
+				tagged = append(tagged, name)
+			}
+		}
+		if len(tagged) == 0 && len(names) > 0 {
+			return nil, false, fmt.Errorf("选中的链接都不带有标签 %s", strings.Join(tags, ", "))
+		}
+		names = tagged
+	}
+	sort.Strings(names)
+	return names, usedGlob, nil
+}
+
+// hasAnyTag 判断链接是否带有 tags 中的任一标签。
+func hasAnyTag(info config.LinkInfo, tags []string) bool {
+	for _, t := range tags {
+		if info.HasTag(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirm 打印提示并从标准输入读取回答，只有输入 y 或 yes 时返回 true。
+// 标准输入已关闭 (例如在脚本中运行) 时视为否定。
+func confirm(prompt string) bool {
+	answer, ok := readLine(fmt.Sprintf("%s [y/N]: ", prompt))
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// readLine 打印提示并读取用户输入的一行 (去掉首尾空白)。没有可读的输入时返回 false。
+func readLine(prompt string) (string, bool) {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return "", false
+	}
+	return strings.TrimSpace(answer), true
+}
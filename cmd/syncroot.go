@@ -0,0 +1,156 @@
+// cmd/syncroot.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"synclink/internal/config"
+	"synclink/internal/link"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// syncRootCmd 代表 root 命令，用于管理命名同步根目录
+var syncRootCmd = &cobra.Command{
+	Use:   "root",
+	Short: "管理命名同步根目录 (例如 dropbox、onedrive)",
+	Long: `管理命名同步根目录。
+
+同步根目录是一个名称 (例如 dropbox) 到本机位置 (例如 D:\Dropbox) 的映射。
+使用 'synclink link --root <名称>' 创建的链接只保存相对于根目录的路径，
+因此当同一个同步盘在不同机器上位于不同位置时，只需在每台机器上设置一次根目录，
+然后运行 synclink relink '*' 即可。
+
+示例:
+  synclink root set dropbox D:\Dropbox
+  synclink root set onedrive %USERPROFILE%\OneDrive
+  synclink root list
+  synclink root remove onedrive`,
+}
+
+var syncRootSetCmd = &cobra.Command{
+	Use:   "set <名称> <路径>",
+	Short: "设置命名同步根目录在本机上的位置",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return err
+		}
+		err = link.UpdateConfig(fmt.Sprintf("将同步根目录 '%s' 设置为 '%s'", args[0], args[1]), func() error {
+			return cfg.SetSyncRoot(args[0], args[1])
+		})
+		if err != nil {
+			return fmt.Errorf("设置同步根目录 '%s' 失败: %w", args[0], err)
+		}
+		rootPath, _ := cfg.GetSyncRoot(args[0])
+		reportf("成功将同步根目录 '%s' 设置为: %s\n", args[0], rootPath)
+		return nil
+	},
+}
+
+var syncRootRemoveCmd = &cobra.Command{
+	Use:   "remove <名称>",
+	Short: "移除一个命名同步根目录",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return err
+		}
+		var removed bool
+		err = link.UpdateConfig(fmt.Sprintf("移除同步根目录 '%s'", args[0]), func() (err error) {
+			removed, err = cfg.RemoveSyncRoot(args[0])
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("未找到名为 '%s' 的同步根目录", args[0])
+		}
+		reportf("已移除同步根目录 '%s'\n", args[0])
+		return nil
+	},
+}
+
+var syncRootListOutput string
+
+// syncRootRecord 是 root list 在 json/csv 格式下输出的记录。
+type syncRootRecord struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Default bool   `json:"default"`
+}
+
+var syncRootListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有命名同步根目录",
+	Long: `列出所有命名同步根目录。
+
+使用 --output json 或 --output csv 输出便于脚本解析的结果，字段为 name、path、default。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(syncRootListOutput)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		roots := cfg.GetSyncRoots()
+		names := make([]string, 0, len(roots))
+		for name := range roots {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		defaultRoot := cfg.GetSettings().DefaultSyncRoot
+
+		switch format {
+		case outputJSON:
+			records := make([]syncRootRecord, 0, len(names))
+			for _, name := range names {
+				records = append(records, syncRootRecord{Name: name, Path: roots[name], Default: name == defaultRoot})
+			}
+			return writeJSON(records)
+		case outputCSV:
+			rows := make([][]string, 0, len(names))
+			for _, name := range names {
+				rows = append(rows, []string{name, roots[name], strconv.FormatBool(name == defaultRoot)})
+			}
+			return writeCSV([]string{"name", "path", "default"}, rows)
+		}
+
+		if len(roots) == 0 {
+			fmt.Println("当前没有配置同步根目录。")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"名称", "本机位置", "默认"})
+		for _, name := range names {
+			isDefault := ""
+			if name == defaultRoot {
+				isDefault = "*"
+			}
+			table.Append([]string{name, roots[name], isDefault})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+func init() {
+	syncRootCmd.AddCommand(syncRootSetCmd)
+	syncRootCmd.AddCommand(syncRootRemoveCmd)
+	syncRootCmd.AddCommand(syncRootListCmd)
+	addOutputFlag(syncRootListCmd, &syncRootListOutput)
+	rootCmd.AddCommand(syncRootCmd)
+}
@@ -0,0 +1,178 @@
+// cmd/tag.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"synclink/internal/config"
+	"synclink/internal/link"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// tagCmd 代表 tag 命令，用于管理链接的标签
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "管理链接的标签 (例如 editor、games、cli)",
+	Long: `管理链接的标签。标签保存在 config.json 中每个链接的 tags 字段，用于按类别选择链接:
+list、relink 和 unlink 都支持 --tag 选择器。创建链接时也可以使用 'synclink link --tag' 直接添加标签。
+
+多个标签用逗号分隔。链接名称可以使用与 unlink、relink 相同的通配符 (需要加引号)。
+
+示例:
+  synclink tag add editor nvim 'vscode-*'
+  synclink tag add games,saves eldenring
+  synclink tag remove editor nvim
+  synclink tag list`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <标签[,标签...]> <link_name|pattern>...",
+	Short: "为链接添加标签",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTagUpdate(args, true)
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove <标签[,标签...]> <link_name|pattern>...",
+	Short: "从链接中移除标签",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTagUpdate(args, false)
+	},
+}
+
+var tagListOutput string
+
+var tagListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有标签及带有该标签的链接",
+	Long: `列出所有标签及带有该标签的链接。
+
+使用 --output json 或 --output csv 输出便于脚本解析的结果，字段为 tag、count、links。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(tagListOutput)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		byTag := make(map[string][]string)
+		for name, info := range cfg.GetLinks() {
+			for _, t := range info.Tags {
+				byTag[t] = append(byTag[t], name)
+			}
+		}
+		tags := make([]string, 0, len(byTag))
+		for t, names := range byTag {
+			sort.Strings(names)
+			tags = append(tags, t)
+		}
+		sort.Strings(tags)
+
+		switch format {
+		case outputJSON:
+			type tagRecord struct {
+				Tag   string   `json:"tag"`
+				Count int      `json:"count"`
+				Links []string `json:"links"`
+			}
+			records := make([]tagRecord, 0, len(tags))
+			for _, t := range tags {
+				records = append(records, tagRecord{Tag: t, Count: len(byTag[t]), Links: byTag[t]})
+			}
+			return writeJSON(records)
+		case outputCSV:
+			rows := make([][]string, 0, len(tags))
+			for _, t := range tags {
+				rows = append(rows, []string{t, strconv.Itoa(len(byTag[t])), strings.Join(byTag[t], ";")})
+			}
+			return writeCSV([]string{"tag", "count", "links"}, rows)
+		}
+
+		if len(tags) == 0 {
+			fmt.Println("当前没有任何链接带有标签。")
+			return nil
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"标签", "链接数", "链接"})
+		for _, t := range tags {
+			table.Append([]string{t, strconv.Itoa(len(byTag[t])), strings.Join(byTag[t], ", ")})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+// runTagUpdate 为 args[1:] 选中的链接添加 (add 为 true) 或移除 args[0] 中的标签。
+func runTagUpdate(args []string, add bool) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	tags, err := config.NormalizeTags(strings.Split(args[0], ","))
+	if err != nil {
+		return err
+	}
+	names, _, err := selectLinks(cfg, args[1:], nil)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("没有找到任何已管理的链接或快捷方式。")
+		return nil
+	}
+
+	tagList := strings.Join(tags, ", ")
+	changedCount := 0
+	for _, name := range names {
+		var changed bool
+		desc := fmt.Sprintf("为链接 '%s' 添加标签 %s", name, tagList)
+		if !add {
+			desc = fmt.Sprintf("从链接 '%s' 移除标签 %s", name, tagList)
+		}
+		err := link.UpdateConfig(desc, func() (err error) {
+			if add {
+				changed, err = cfg.AddTags(name, tags)
+			} else {
+				changed, err = cfg.RemoveTags(name, tags)
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("更新链接 '%s' 的标签失败: %w", name, err)
+		}
+		if changed {
+			changedCount++
+		}
+	}
+
+	if add {
+		reportf("已为 %d 个链接添加标签 %s (%d 个链接已带有这些标签)。\n", changedCount, tagList, len(names)-changedCount)
+	} else {
+		reportf("已从 %d 个链接移除标签 %s (%d 个链接原本就不带有这些标签)。\n", changedCount, tagList, len(names)-changedCount)
+	}
+	return nil
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+	rootCmd.AddCommand(tagCmd)
+
+	addOutputFlag(tagListCmd, &tagListOutput)
+}
@@ -11,11 +11,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	unlinkYes  bool
+	unlinkTags []string
+)
+
 // unlinkCmd represents the unlink command
 var unlinkCmd = &cobra.Command{
-	Use:   "unlink <link_name>",
-	Short: "移除一个已管理的链接或快捷方式",
-	Long: `根据名称移除一个由 synclink 管理的符号链接或快捷方式。
+	Use:   "unlink [link_name|pattern]... [--tag <标签>]",
+	Short: "移除一个或多个已管理的链接或快捷方式",
+	Long: `根据名称移除由 synclink 管理的符号链接或快捷方式。
 
 对于符号链接：
 1. synclink 会删除在原始位置创建的符号链接。
@@ -26,60 +31,83 @@ var unlinkCmd = &cobra.Command{
 1. synclink 会删除在启动菜单中创建的快捷方式文件。
 2. synclink 会从配置文件中移除该快捷方式的记录。
 
-特别地，如果 link_name 是 '*'，则会尝试移除所有当前管理的链接和快捷方式。`,
-	Args: cobra.ExactArgs(1), // 必须提供一个参数：链接名称或 '*'
+可以同时指定多个名称，也可以使用 shell 风格的通配符 (*、?、[...]) 匹配配置中的链接名称，
+例如 'vscode-*'；特别地，'*' 会匹配所有当前管理的链接和快捷方式。通配符需要加引号，避免被 shell 展开。
+使用 --tag 只选择带有指定标签的链接 (多个标签之间为 "或" 的关系)；只指定 --tag 时从所有链接中选择。
+使用通配符、标签或选中多个链接时，会先列出匹配的链接并请求确认；使用 --yes 跳过确认。
+
+示例:
+  synclink unlink uv
+  synclink unlink nvim wezterm starship
+  synclink unlink 'vscode-*'
+  synclink unlink --tag games
+  synclink unlink '*' --yes`,
+	Args: linkSelectionArgs(&unlinkTags), // 至少提供一个链接名称、通配符或标签
 	RunE: func(cmd *cobra.Command, args []string) error {
-		linkName := args[0]
-
 		cfg, err := config.GetConfig()
 		if err != nil {
 			return err
 		}
 
-		// 处理通配符 '*'
-		if linkName == "*" {
-			allLinks := cfg.GetLinks() // 获取所有链接信息的副本
-			if len(allLinks) == 0 {
-				fmt.Println("没有找到任何已管理的链接或快捷方式。")
-				return nil
-			}
+		names, usedGlob, err := selectLinks(cfg, args, unlinkTags)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Println("没有找到任何已管理的链接或快捷方式。")
+			return nil
+		}
 
-			successCount := 0
-			failCount := 0
-
-			for name := range allLinks {
-				err := link.RemoveLinkOrShortcut(name) // 核心移除逻辑
-				if err != nil {
-					util.ErrorPrint("[-] 移除 '%s' 失败: %v\n", name, err)
-				} else {
-					fmt.Printf("[-] 已移除 '%s'\n", name)
-					successCount++
-				}
+		// 处理单个链接名称
+		if len(names) == 1 && !usedGlob {
+			err = link.RemoveLinkOrShortcut(names[0])
+			if err != nil {
+				return err
 			}
-			fmt.Println("\n移除链接操作完成。")
-			fmt.Printf("总计：%d 个链接\n", len(allLinks))
-			fmt.Printf("成功：%d 个\n", successCount)
-			fmt.Printf("失败：%d 个\n", failCount)
 
+			reportf("已成功移除 '%s'\n", names[0])
 			return nil
 		}
 
-		// 处理单个链接名称
-		_, exists := cfg.GetLink(linkName)
-		if !exists {
-			return fmt.Errorf("未在配置中找到名为 '%s' 的链接或快捷方式", linkName)
+		// 处理多个链接或通配符：列出匹配的链接并确认
+		fmt.Printf("将移除以下 %d 个链接 (符号链接的数据会被移回原始位置):\n", len(names))
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
 		}
-
-		err = link.RemoveLinkOrShortcut(linkName)
-		if err != nil {
-			return err
+		if !dryRun && !unlinkYes && !confirm("确认移除？") {
+			fmt.Println("已取消。")
+			return nil
 		}
 
-		fmt.Printf("已成功移除 '%s'\n", linkName)
+		successCount := 0
+		failCount := 0
+
+		for _, name := range names {
+			err := link.RemoveLinkOrShortcut(name) // 核心移除逻辑
+			if err != nil {
+				util.ErrorPrint("[-] 移除 '%s' 失败: %v\n", name, err)
+				failCount++
+			} else {
+				reportf("[-] 已移除 '%s'\n", name)
+				successCount++
+			}
+		}
+		reportf("\n移除链接操作完成。\n")
+		reportf("总计：%d 个链接\n", len(names))
+		reportf("成功：%d 个\n", successCount)
+		reportf("失败：%d 个\n", failCount)
+
+		if failCount > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("有 %d 个链接移除失败", failCount)
+		}
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(unlinkCmd) // 将 unlink 命令添加到根命令
+
+	unlinkCmd.Flags().BoolVarP(&unlinkYes, "yes", "y", false, "不请求确认，直接移除匹配的链接")
+	unlinkCmd.Flags().StringSliceVarP(&unlinkTags, "tag", "t", nil, "只选择带有指定标签的链接 (可重复使用或用逗号分隔)")
 }
@@ -0,0 +1,193 @@
+// internal/config/backup.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"synclink/internal/util"
+)
+
+// DefaultConfigBackups 是未设置 config_backups 时保留的 config.json 备份数量。
+const DefaultConfigBackups = 5
+
+// backupTimeFormat 是备份文件名中的时间格式，例如 config.json.20261016-153000.bak。
+const backupTimeFormat = "20060102-150405"
+
+// restoreBackupHint 附加在配置文件无法加载的错误之后。
+const restoreBackupHint = "可以使用 'synclink config restore-backup' 从自动备份中恢复"
+
+// Backup 描述一个 config.json 的备份。
+type Backup struct {
+	Path  string
+	Time  time.Time
+	Size  int64
+	Links int  // 备份中的链接数量
+	Valid bool // 备份是否为可以加载的配置文件
+}
+
+// backupPattern 返回匹配配置文件 path 所有备份的通配符。
+func backupPattern(path string) string {
+	return path + ".*.bak"
+}
+
+// backupFile 把 path 当前的内容复制为同一目录下带时间戳的备份，并返回备份的路径。
+// 文件不存在或为空时不做任何事，返回空字符串。
+func backupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("读取配置文件 '%s' 失败: %w", path, err)
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	stamp := time.Now().Format(backupTimeFormat)
+	dst := fmt.Sprintf("%s.%s.bak", path, stamp)
+	// 同一秒内多次备份时追加序号，不覆盖已有的备份
+	for i := 1; ; i++ {
+		if exists, err := util.PathExists(dst); err != nil {
+			return "", err
+		} else if !exists {
+			break
+		}
+		dst = fmt.Sprintf("%s.%s-%d.bak", path, stamp, i)
+	}
+
+	if err := util.WriteFileAtomic(dst, data, 0644); err != nil {
+		return "", fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	return dst, nil
+}
+
+// rotateBackups 备份 path 当前的内容，并只保留最新的 keep 个备份。keep 为 0 时不备份并删除所有旧备份。
+// 备份失败只打印警告：它不应阻止保存配置。
+func rotateBackups(path string, keep int) {
+	if keep > 0 {
+		if _, err := backupFile(path); err != nil {
+			util.WarningPrint("警告: %v\n", err)
+		}
+	}
+	pruneBackups(path, keep)
+}
+
+// pruneBackups 删除 path 的旧备份，只保留最新的 keep 个。删除失败只打印警告。
+func pruneBackups(path string, keep int) {
+	backups, err := listBackups(path)
+	if err != nil {
+		util.WarningPrint("警告: %v\n", err)
+		return
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil && !os.IsNotExist(err) {
+			util.WarningPrint("警告: 删除旧的配置备份 '%s' 失败: %v\n", backups[i].Path, err)
+		}
+	}
+}
+
+// ListBackups 返回 config.json 的所有备份，最新的在前。
+func ListBackups() ([]Backup, error) {
+	path, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return listBackups(path)
+}
+
+func listBackups(path string) ([]Backup, error) {
+	matches, err := filepath.Glob(backupPattern(path))
+	if err != nil {
+		return nil, fmt.Errorf("查找配置备份失败: %w", err)
+	}
+
+	var backups []Backup
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		b := Backup{Path: m, Time: info.ModTime(), Size: info.Size()}
+		if cfg, err := parseBackup(m); err == nil {
+			b.Links, b.Valid = len(cfg.Links), true
+		}
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backups[i].Path > backups[j].Path
+	})
+	return backups, nil
+}
+
+// parseBackup 读取并解析一个备份，确认它是可以加载的配置文件。
+func parseBackup(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份 '%s' 失败: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("备份 '%s' 为空", path)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("备份 '%s' 不是有效的配置文件: %w", path, err)
+	}
+	// 旧版本的备份在恢复后加载时会被自动迁移，更新版本的备份则无法加载
+	version, _ := fileVersion(data)
+	if _, err := pendingMigrations(version); err != nil {
+		return nil, fmt.Errorf("无法使用备份 '%s': %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// RestoreBackup 用备份 path 覆盖 config.json。覆盖之前，当前的 config.json 也会被保存为备份，
+// 因此恢复本身可以撤销；超出 config_backups 的旧备份随后被删除。
+// 此函数不要求当前的配置文件可以加载；恢复后需要重新运行命令才会使用恢复的配置。
+// 在 dry-run 模式下只检查备份是否有效。
+func RestoreBackup(path string) error {
+	cfgPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	restored, err := parseBackup(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取备份 '%s' 失败: %w", path, err)
+	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	if dryRun {
+		return nil
+	}
+	lock, err := lockConfig(cfgPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if _, err := backupFile(cfgPath); err != nil {
+		return fmt.Errorf("恢复之前%w", err)
+	}
+	backedUp = true // 当前内容已经备份过，本进程之后的保存不再重复备份
+	// 与保存配置时相同，按恢复后生效的 config_backups 删除多余的旧备份，但至少保留刚创建的这一个，以便撤销恢复
+	pruneBackups(cfgPath, max(effectiveSettings(restored.Settings).ConfigBackupCount(), 1))
+	if err := util.WriteFileAtomic(cfgPath, data, 0644); err != nil {
+		return fmt.Errorf("写入配置文件 '%s' 失败: %w", cfgPath, err)
+	}
+	baseline = data
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,21 +16,95 @@ import (
 )
 
 // ConfigFileName 是配置文件的名称。
-// 导出以便其他包需要时使用，尽管优先使用 GetConfigPath。
+// 导出以便其他包需要时使用，尽管优先使用 Path。
 const ConfigFileName = "config.json"
-const CurrentVersion = "1.0" // 定义配置结构的当前版本
+const CurrentVersion = "1.1" // 定义配置结构的当前版本，提升时需要在 migrations 中追加迁移步骤
 
 // Settings 保存应用程序的一般设置。
 type Settings struct {
-	DefaultSyncPath string `json:"default_sync_path"`
+	DefaultSyncPath   string            `json:"default_sync_path"`
+	DefaultSyncRoot   string            `json:"default_sync_root,omitempty"`   // link 未指定 --root 或 -s 时使用的命名同步根目录
+	SyncRoots         map[string]string `json:"sync_roots,omitempty"`          // 命名同步根目录 (例如 dropbox) 到本机位置的映射
+	ConfigBackups     *int              `json:"config_backups,omitempty"`      // 保留的 config.json 备份数量，未设置时为 DefaultConfigBackups
+	RestoreOnConflict string            `json:"restore_on_conflict,omitempty"` // restore 默认的冲突处理策略
+	VerifyCopy        *bool             `json:"verify_copy,omitempty"`         // 跨磁盘移动时是否校验副本，未设置时为 true
+	LockTimeout       string            `json:"lock_timeout,omitempty"`        // 等待配置文件锁的最长时间，例如 30s
+}
+
+// 设置项的类型、默认值和说明登记在 settings.go 的 settingDefs 中。
+
+// ConfigBackupCount 返回保留的 config.json 备份数量。
+func (s Settings) ConfigBackupCount() int {
+	if s.ConfigBackups == nil {
+		return DefaultConfigBackups
+	}
+	return *s.ConfigBackups
 }
 
 // LinkInfo 保存单个管理链接的详细信息。
 type LinkInfo struct {
 	Shortcut     bool      `json:"shortcut"`              // 如果这是快捷方式则为 true，如果是符号链接则为 false
-	OriginalPath string    `json:"original_path"`         // 文件/文件夹的原始位置
-	SyncedPath   string    `json:"synced_path,omitempty"` // 实际数据存储位置（仅针对符号链接）
+	OriginalPath string    `json:"original_path"`         // 文件/文件夹的原始位置，可能包含环境变量令牌
+	SyncedPath   string    `json:"synced_path,omitempty"` // 实际数据存储位置（仅针对符号链接）；设置了 SyncRoot 时为相对于该根目录的路径
+	SyncRoot     string    `json:"sync_root,omitempty"`   // SyncedPath 所相对的命名同步根目录，为空表示 SyncedPath 是绝对路径
+	Kind         string    `json:"kind,omitempty"`        // 同步数据的类型 (KindFile 或 KindDir)；快捷方式以及旧版本创建的链接为空
 	CreatedAt    time.Time `json:"created_at"`            // 链接创建的时间
+	Tags         []string  `json:"tags,omitempty"`        // 用于分组选择的标签 (已去重并排序)
+	Mode         string    `json:"mode,omitempty"`        // 链接模式：为空表示整个文件或文件夹替换为一个符号链接，ModeTree 表示逐个文件链接
+	Files        []string  `json:"files,omitempty"`       // tree 模式下管理的文件，相对于 OriginalPath 和 SyncedPath，使用 '/' 分隔
+	Include      []string  `json:"include,omitempty"`     // tree 模式的包含规则：非空时只同步匹配的文件
+	Exclude      []string  `json:"exclude,omitempty"`     // tree 模式的排除规则：匹配的文件留在本机
+}
+
+// ModeTree 是 stow 风格的链接模式：SyncedPath 是同步目录中的包目录，OriginalPath 保持为真实目录，
+// 其中 Files 列出的每个文件分别是指向包目录中对应文件的符号链接。
+const ModeTree = "tree"
+
+// 同步数据的类型，创建或恢复链接时记录在 LinkInfo.Kind 中。
+const (
+	KindFile = "file"
+	KindDir  = "dir"
+)
+
+// IsTree 判断链接是否为逐个文件链接的 tree 模式。
+func (l LinkInfo) IsTree() bool {
+	return l.Mode == ModeTree
+}
+
+// HasTag 判断链接是否带有指定标签。
+func (l LinkInfo) HasTag(tag string) bool {
+	for _, t := range l.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags 校验并整理标签：去掉首尾空白、去重并排序。
+// 标签不能为空，也不能包含空白字符或逗号 (命令行中用逗号分隔多个标签)。
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || strings.ContainsAny(t, ", \t\r\n") {
+			return nil, fmt.Errorf("无效的标签 '%s'：标签不能为空，也不能包含空白字符或逗号", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Expanded 返回展开了路径中环境变量令牌 (例如 %LOCALAPPDATA%、$HOME) 的副本。
+// 它不解析同步根目录，需要完整解析时请使用 Config.ResolveLink。
+func (l LinkInfo) Expanded() LinkInfo {
+	l.OriginalPath = util.ExpandPath(l.OriginalPath)
+	return l
 }
 
 // Config 是应用程序配置的根结构体。
@@ -42,31 +119,25 @@ var (
 	once           sync.Once
 	configMutex    sync.RWMutex // 保护对配置和文件的并发访问的互斥锁
 	configPath     string       // 缓存配置路径
+	pathOnce       sync.Once    // 保证配置路径只确定一次
+	pathErr        error        // 确定配置路径时的错误
+	dryRun         bool         // 为 true 时 SaveConfig 只更新内存中的配置，不写入文件
+	backedUp       bool         // 本进程是否已在保存之前备份过配置文件
 )
 
-// init 函数一次性确定配置路径。
-func init() {
-	var err error
-	configPath, err = util.GetConfigPath()
-	if err != nil {
-		// 如果我们甚至无法确定配置路径，那就是一个严重的启动错误。
-		// 我们可能想要恐慌或致命日志，具体取决于所需的健壮性。
-		// 现在，我们打印错误并继续，LoadConfig 会再次处理它。
-		fmt.Fprintf(os.Stderr, "初始化期间确定配置路径时出错: %v\n", err)
-		// 将 configPath 设置为空字符串将导致 LoadConfig 后续失败
-		configPath = ""
-	}
+// getConfigPath 返回本次运行使用的配置文件路径。第一次调用时确定路径 (见 resolveConfigPath)，
+// 之后返回同一个结果，因此必须在处理 --config 标志和 --dry-run 之后才调用。
+// 第一次调用可能会把旧位置的配置文件移动过来 (见 relocateConfig)，因此调用时不能持有 configMutex。
+func getConfigPath() (string, error) {
+	pathOnce.Do(func() {
+		configPath, pathErr = resolveConfigPath()
+	})
+	return configPath, pathErr
 }
 
-// getConfigPath 返回缓存的配置路径。
-// 它集中处理路径逻辑和处理初始化错误的情况。
-func getConfigPath() (string, error) {
-	if configPath == "" {
-		// 这可能意味着 init() 函数失败。尝试重新获取或返回错误。
-		// 或者，依靠最初的错误打印返回一个通用错误。
-		return "", fmt.Errorf("在启动时无法确定配置路径")
-	}
-	return configPath, nil
+// Path 返回本次运行使用的配置文件路径。
+func Path() (string, error) {
+	return getConfigPath()
 }
 
 // LoadConfig 从 JSON 文件加载配置。
@@ -84,6 +155,18 @@ func LoadConfig() (*Config, error) {
 		configMutex.Lock() // 锁定以进行初始加载/创建
 		defer configMutex.Unlock()
 
+		// 锁文件与配置文件位于同一目录，因此需要先确保目录存在
+		if err := util.EnsureDirExists(filepath.Dir(cfgPath)); err != nil {
+			loadErr = fmt.Errorf("无法创建配置文件 '%s' 的目录: %w", cfgPath, err)
+			return
+		}
+		lock, err := lockConfig(cfgPath)
+		if err != nil {
+			loadErr = err
+			return
+		}
+		defer lock.Unlock()
+
 		// 检查文件是否存在
 		exists, err := util.PathExists(cfgPath)
 		if err != nil {
@@ -92,7 +175,8 @@ func LoadConfig() (*Config, error) {
 		}
 
 		if !exists {
-			fmt.Printf("在 '%s' 找不到配置文件. 正在创建默认配置文件.\n", cfgPath)
+			// 提示写到标准错误，不混入 list --output json 等命令的机器可读输出
+			fmt.Fprintf(os.Stderr, "在 '%s' 找不到配置文件. 正在创建默认配置文件.\n", cfgPath)
 			// 创建默认配置
 			defaultConfig := &Config{
 				Settings: Settings{
@@ -102,18 +186,13 @@ func LoadConfig() (*Config, error) {
 				Links:   make(map[string]LinkInfo),
 				Version: CurrentVersion,
 			}
-			// 确保在保存之前目录存在
-			if err := util.EnsureDirExists(filepath.Dir(cfgPath)); err != nil {
-				loadErr = fmt.Errorf("无法创建配置文件 '%s' 的目录: %w", cfgPath, err)
-				return // 如果目录创建失败则不尝试保存
-			}
 			// 保存默认配置
 			if err := saveConfigInternal(cfgPath, defaultConfig); err != nil {
 				loadErr = fmt.Errorf("无法保存默认配置文件 '%s': %w", cfgPath, err)
 				return // 保存默认配置失败，不分配它
 			}
 			configInstance = defaultConfig
-			fmt.Println("默认配置文件创建成功.")
+			fmt.Fprintln(os.Stderr, "默认配置文件创建成功.")
 		} else {
 			// 文件存在，加载它
 			data, err := os.ReadFile(cfgPath)
@@ -122,51 +201,38 @@ func LoadConfig() (*Config, error) {
 				return
 			}
 
-			// 检查文件是否为空
+			// 写入是原子的，正常情况下不会出现空文件。不能把它当作没有任何链接的配置继续使用，
+			// 否则下一次保存就会覆盖掉所有记录
 			if len(data) == 0 {
-				// 处理空文件情况 - 或许将其视为缺失？或者记录警告？
-				// 让我们尝试解组，可能会出错或导致零值结构体。
-				util.WarningPrint("配置文件 '%s' 为空。", cfgPath)
-				// 使用默认结构来避免后续的 nil 指针解引用
-				configInstance = &Config{
-					Settings: Settings{},
-					Links:    make(map[string]LinkInfo),
-					Version:  "", // 版本将为空，可能需要更新逻辑
-				}
-				// 尝试解组，也许它只是 `{}`
-				if err := json.Unmarshal(data, configInstance); err != nil && string(data) != "{}" && string(data) != "null" {
-					// 如果解组失败并且不只是空对象/空值，报告错误
-					loadErr = fmt.Errorf("解析空或无效配置文件 '%s' 失败: %w", cfgPath, err)
-					configInstance = nil // 确保在错误时实例为 nil
-					return
-				}
-				// 如果解组成功（例如来自 `{}`），确保 Links 映射被初始化
-				if configInstance.Links == nil {
-					configInstance.Links = make(map[string]LinkInfo)
-				}
-
-			} else {
-				// 文件非空，尝试解组
-				var cfg Config
-				// 在解组之前初始化映射，以避免如果 JSON 中缺少 "links" 时的 nil 映射
+				loadErr = fmt.Errorf("配置文件 '%s' 为空。%s", cfgPath, restoreBackupHint)
+				return
+			}
+
+			var probe any
+			if err := json.Unmarshal(data, &probe); err != nil {
+				loadErr = fmt.Errorf("解析配置文件 '%s' 失败: %w。%s", cfgPath, err, restoreBackupHint)
+				return
+			}
+			// 旧版本的配置文件先备份再升级；比此 synclink 更新的版本拒绝加载，以免保存时丢失不认识的字段
+			data, err = migrateFile(cfgPath, data)
+			if err != nil {
+				loadErr = fmt.Errorf("配置文件 '%s': %w", cfgPath, err)
+				return
+			}
+
+			var cfg Config
+			// 在解组之前初始化映射，以避免如果 JSON 中缺少 "links" 时的 nil 映射
+			cfg.Links = make(map[string]LinkInfo)
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				loadErr = fmt.Errorf("解析配置文件 '%s' 失败: %w。%s", cfgPath, err, restoreBackupHint)
+				return
+			}
+			baseline = data
+			// 即使在 JSON 中为 null 也确保 Links 映射被初始化
+			if cfg.Links == nil {
 				cfg.Links = make(map[string]LinkInfo)
-				if err := json.Unmarshal(data, &cfg); err != nil {
-					loadErr = fmt.Errorf("解析配置文件 '%s' 失败: %w", cfgPath, err)
-					return
-				}
-				// TODO: 如果需要，添加版本检查和潜在的迁移逻辑
-				if cfg.Version != CurrentVersion {
-					// 处理版本不匹配 - 可能迁移或警告用户
-					util.WarningPrint("配置文件版本不匹配（发现 '%s'，需要 '%s'），可能会出现兼容性问题。", cfg.Version, CurrentVersion)
-					// 现在，我们继续，但更新内存中的版本
-					cfg.Version = CurrentVersion // 或执行实际迁移
-				}
-				// 即使在 JSON 中为 null 也确保 Links 映射被初始化
-				if cfg.Links == nil {
-					cfg.Links = make(map[string]LinkInfo)
-				}
-				configInstance = &cfg
 			}
+			configInstance = &cfg
 		}
 	}) // 结束 once.Do
 
@@ -202,18 +268,36 @@ func GetConfig() (*Config, error) {
 }
 
 // SaveConfig 将当前配置状态保存到 JSON 文件中。
-// 它获取写入锁以确保安全的并发访问。
+// 它获取写入锁以确保安全的并发访问，并持有配置文件锁：写入之前重新读取文件，
+// 合并其他 synclink 进程在本进程加载之后所做的修改。
 func SaveConfig() error {
+	cfgPath, err := getConfigPath()
+	if err != nil {
+		return fmt.Errorf("获取保存用配置文件路径失败: %w", err)
+	}
+	return saveConfigTo(cfgPath)
+}
+
+// saveConfigTo 执行 SaveConfig 的加锁、合并和写入，把当前配置保存到 cfgPath。
+func saveConfigTo(cfgPath string) error {
 	configMutex.Lock() // 使用写锁进行保存
 	defer configMutex.Unlock()
 
 	if configInstance == nil {
 		return fmt.Errorf("配置未加载，无法保存")
 	}
+	if dryRun {
+		return nil
+	}
 
-	cfgPath, err := getConfigPath()
+	lock, err := lockConfig(cfgPath)
 	if err != nil {
-		return fmt.Errorf("获取保存用配置文件路径失败: %w", err)
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := mergeFromDisk(cfgPath, configInstance); err != nil {
+		return err
 	}
 
 	// 在保存之前更新版本（如有必要）
@@ -222,7 +306,15 @@ func SaveConfig() error {
 	return saveConfigInternal(cfgPath, configInstance)
 }
 
-// saveConfigInternal 执行实际的保存逻辑。假设持有锁。
+// SetDryRun 开启或关闭 dry-run 模式。开启后修改配置的函数照常校验参数并更新内存中的配置，
+// 但 SaveConfig 不再写入文件。
+func SetDryRun(enabled bool) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	dryRun = enabled
+}
+
+// saveConfigInternal 执行实际的保存逻辑。假设持有 configMutex 和配置文件锁。
 func saveConfigInternal(path string, cfg *Config) error {
 	// 确保目录存在
 	dir := filepath.Dir(path)
@@ -236,50 +328,121 @@ func saveConfigInternal(path string, cfg *Config) error {
 		return fmt.Errorf("将配置序列化为 JSON 失败: %w", err)
 	}
 
-	// 写入文件（os.WriteFile 处理创建/截断和关闭）
+	// 每个进程第一次覆盖配置文件之前，先保存修改前的内容
+	if !backedUp {
+		backedUp = true
+		rotateBackups(path, effectiveSettings(cfg.Settings).ConfigBackupCount())
+	}
+
+	// 先写入临时文件并刷新到磁盘，再重命名覆盖：崩溃或磁盘已满时旧文件保持完整
 	// 使用 0644 权限：所有者读/写，组读，其他人读
-	err = os.WriteFile(path, data, 0644)
-	if err != nil {
+	if err := util.WriteFileAtomic(path, data, 0644); err != nil {
 		return fmt.Errorf("写入配置文件 '%s' 失败: %w", path, err)
 	}
+	baseline = data
 
 	return nil
 }
 
 // === 访问/修改配置的辅助函数 ===
 
-// GetSettings 返回当前设置。
+// GetSettings 返回当前设置，其中已应用环境变量 (例如 SYNCLINK_DEFAULT_SYNC_PATH) 的覆盖。
 // 建议在确保通过 GetConfig() 加载配置后使用。
 func (c *Config) GetSettings() Settings {
 	configMutex.RLock() // 读锁
 	defer configMutex.RUnlock()
 	// 返回副本以防止未使用 Setters 的修改？
 	// 对于这个内部包，直接访问可能没问题，或者提供特定的获取器。
-	return c.Settings
+	return effectiveSettings(c.Settings)
 }
 
 // GetDefaultSyncPath 获取默认同步路径。
 func (c *Config) GetDefaultSyncPath() (string, error) {
 	configMutex.RLock()
 	defer configMutex.RUnlock()
-	if c.Settings.DefaultSyncPath == "" {
+	settings := effectiveSettings(c.Settings)
+	if settings.DefaultSyncPath == "" {
 		return "", fmt.Errorf("配置中未设置 DefaultSyncPath。请使用 'synclink config set default_sync_path <path>'")
 	}
-	return c.Settings.DefaultSyncPath, nil
+	return settings.DefaultSyncPath, nil
 }
 
-// SetDefaultSyncPath 设置默认同步路径并保存配置。
-func (c *Config) SetDefaultSyncPath(newPath string) error {
-	absPath, err := util.GetAbsPath(newPath) // 存储绝对路径
+// GetSyncRoots 返回所有命名同步根目录的副本。
+func (c *Config) GetSyncRoots() map[string]string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	roots := make(map[string]string, len(c.Settings.SyncRoots))
+	for k, v := range c.Settings.SyncRoots {
+		roots[k] = v
+	}
+	return roots
+}
+
+// GetSyncRoot 返回命名同步根目录在本机上的位置 (已展开环境变量令牌)。
+func (c *Config) GetSyncRoot(name string) (string, error) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	p, ok := c.Settings.SyncRoots[name]
+	if !ok || p == "" {
+		return "", fmt.Errorf("本机未配置同步根目录 '%s'。请使用 'synclink root set %s <path>' 设置", name, name)
+	}
+	return util.ExpandPath(p), nil
+}
+
+// SetSyncRoot 设置命名同步根目录在本机上的位置并保存配置。
+func (c *Config) SetSyncRoot(name, newPath string) error {
+	absPath, err := util.GetAbsPath(util.ExpandPath(newPath))
 	if err != nil {
 		return fmt.Errorf("无效路径 '%s': %w", newPath, err)
 	}
 
-	configMutex.Lock() // 完全锁定以便修改
-	c.Settings.DefaultSyncPath = absPath
-	configMutex.Unlock() // 在保存之前解锁
+	configMutex.Lock()
+	if c.Settings.SyncRoots == nil {
+		c.Settings.SyncRoots = make(map[string]string)
+	}
+	c.Settings.SyncRoots[name] = absPath
+	configMutex.Unlock()
+
+	return SaveConfig()
+}
+
+// RemoveSyncRoot 移除命名同步根目录并保存配置。
+// 如果根目录存在并被移除，则返回 true。仍被链接引用的根目录不能被移除。
+func (c *Config) RemoveSyncRoot(name string) (bool, error) {
+	configMutex.Lock()
+	if _, ok := c.Settings.SyncRoots[name]; !ok {
+		configMutex.Unlock()
+		return false, nil
+	}
+	for linkName, info := range c.Links {
+		if info.SyncRoot == name {
+			configMutex.Unlock()
+			return false, fmt.Errorf("同步根目录 '%s' 仍被链接 '%s' 使用，无法移除", name, linkName)
+		}
+	}
+	delete(c.Settings.SyncRoots, name)
+	if c.Settings.DefaultSyncRoot == name {
+		c.Settings.DefaultSyncRoot = ""
+	}
+	configMutex.Unlock()
+
+	return true, SaveConfig()
+}
 
-	return SaveConfig() // 保存隐式地再次处理锁定
+// ResolveLink 返回链接信息在本机上的实际形式：
+// 展开 OriginalPath 中的环境变量令牌，并将相对于命名同步根目录的 SyncedPath 解析为绝对路径。
+// 配置中保存的是可移植的原始形式，实际操作文件系统前都应先调用它。
+func (c *Config) ResolveLink(info LinkInfo) (LinkInfo, error) {
+	info = info.Expanded()
+	if info.SyncRoot != "" {
+		root, err := c.GetSyncRoot(info.SyncRoot)
+		if err != nil {
+			return info, err
+		}
+		info.SyncedPath = filepath.Join(root, info.SyncedPath)
+		info.SyncRoot = ""
+	}
+	return info, nil
 }
 
 // GetLinks 返回所有管理链接的映射。
@@ -317,6 +480,63 @@ func (c *Config) AddLink(name string, info LinkInfo) error {
 	return SaveConfig()
 }
 
+// AddTags 为链接添加标签 (应已通过 NormalizeTags 整理) 并保存配置。
+// 返回链接的标签是否发生了变化；没有变化时不保存。
+func (c *Config) AddTags(name string, tags []string) (bool, error) {
+	return c.updateTags(name, func(info LinkInfo) []string {
+		return append(append([]string(nil), info.Tags...), tags...)
+	})
+}
+
+// RemoveTags 从链接中移除标签并保存配置。
+// 返回链接的标签是否发生了变化；没有变化时不保存。
+func (c *Config) RemoveTags(name string, tags []string) (bool, error) {
+	return c.updateTags(name, func(info LinkInfo) []string {
+		var kept []string
+		for _, t := range info.Tags {
+			if !slices.Contains(tags, t) {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	})
+}
+
+// SetTags 把链接的标签替换为 tags 并保存配置。
+// 返回链接的标签是否发生了变化；没有变化时不保存。
+func (c *Config) SetTags(name string, tags []string) (bool, error) {
+	return c.updateTags(name, func(LinkInfo) []string {
+		return tags
+	})
+}
+
+// updateTags 用 update 计算链接的新标签，发生变化时保存配置。
+func (c *Config) updateTags(name string, update func(LinkInfo) []string) (bool, error) {
+	configMutex.Lock()
+	info, ok := c.Links[name]
+	if !ok {
+		configMutex.Unlock()
+		return false, fmt.Errorf("链接 '%s' 未在配置中找到", name)
+	}
+	newTags, err := NormalizeTags(update(info))
+	if err != nil {
+		configMutex.Unlock()
+		return false, err
+	}
+	if slices.Equal(newTags, info.Tags) {
+		configMutex.Unlock()
+		return false, nil
+	}
+	if len(newTags) == 0 {
+		newTags = nil
+	}
+	info.Tags = newTags
+	c.Links[name] = info
+	configMutex.Unlock()
+
+	return true, SaveConfig()
+}
+
 // RemoveLink 通过其名称移除链接条目并保存配置。
 // 如果链接存在并被移除，则返回 true，否则返回 false。
 func (c *Config) RemoveLink(name string) (bool, error) {
@@ -0,0 +1,95 @@
+// internal/config/location.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"synclink/internal/util"
+)
+
+// legacyJournalDir 是旧位置上操作日志目录的名称，与 journal.DirName 相同 (journal 包依赖本包，不能反向引用)。
+const legacyJournalDir = "journal"
+
+// resolveConfigPath 确定本次运行使用的配置文件路径 (见 util.GetConfigPath)。
+// 用户没有显式指定位置、默认位置还没有配置文件，而旧版本使用的可执行文件同目录下有配置文件时，
+// 先把它连同备份和操作日志移动到默认位置。移动失败时本次继续使用旧位置。
+func resolveConfigPath() (string, error) {
+	path, explicit, err := util.GetConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("确定配置文件路径失败: %w", err)
+	}
+	if explicit {
+		return path, nil
+	}
+
+	legacy, err := util.LegacyConfigPath()
+	if err != nil || legacy == path {
+		return path, nil
+	}
+	if exists, _ := util.PathExists(path); exists {
+		return path, nil
+	}
+	if exists, _ := util.PathExists(legacy); !exists {
+		return path, nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "[dry-run] 配置文件将从旧位置 '%s' 移动到 '%s'，本次继续使用旧位置。\n", legacy, path)
+		return legacy, nil
+	}
+	if err := relocateConfig(legacy, path); err != nil {
+		util.WarningPrint("警告: 无法将配置文件从旧位置 '%s' 移动到 '%s': %v。本次继续使用旧位置。\n", legacy, path, err)
+		return legacy, nil
+	}
+	return path, nil
+}
+
+// relocateConfig 把旧位置 legacy 的配置文件移动到 path，并尽量一起移动它的备份和操作日志。
+// 配置文件先复制再删除：旧位置可能是只读的安装目录，无法删除时只打印警告，旧文件之后不会再被使用。
+// 与其他使用配置文件锁的地方相同，移动期间持有 configMutex，因此调用者不能持有它。
+func relocateConfig(legacy, path string) error {
+	dir := filepath.Dir(path)
+	if err := util.EnsureDirExists(dir); err != nil {
+		return err
+	}
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	lock, err := lockConfig(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	if exists, _ := util.PathExists(path); exists {
+		return nil // 另一个进程刚刚完成了移动
+	}
+
+	data, err := os.ReadFile(legacy)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(path, data, 0644); err != nil {
+		return err
+	}
+
+	legacyDir := filepath.Dir(legacy)
+	if exists, _ := util.PathExists(filepath.Join(legacyDir, legacyJournalDir)); exists {
+		if err := util.MoveFileOrDir(filepath.Join(legacyDir, legacyJournalDir), filepath.Join(dir, legacyJournalDir)); err != nil {
+			util.WarningPrint("警告: 移动操作日志目录失败: %v。被中断的操作需要使用 '--config %s' 运行 'synclink recover' 处理\n", err, legacy)
+		}
+	}
+	backups, _ := filepath.Glob(backupPattern(legacy))
+	for _, b := range backups {
+		if err := util.MoveFileOrDir(b, filepath.Join(dir, filepath.Base(b))); err != nil {
+			util.WarningPrint("警告: 移动配置备份 '%s' 失败: %v\n", b, err)
+		}
+	}
+
+	if err := os.Remove(legacy); err != nil {
+		util.WarningPrint("警告: 无法删除旧的配置文件 '%s': %v。它不会再被使用，可以手动删除\n", legacy, err)
+	}
+	os.Remove(legacy + ".lock")
+	fmt.Fprintf(os.Stderr, "已将配置文件从旧位置 '%s' 移动到 '%s'。\n", legacy, path)
+	return nil
+}
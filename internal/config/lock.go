@@ -0,0 +1,183 @@
+// internal/config/lock.go
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"synclink/internal/util"
+)
+
+// defaultLockTimeout 是未设置 lock_timeout 时等待其他 synclink 进程释放配置文件锁的最长时间。
+const defaultLockTimeout = 30 * time.Second
+
+// baseline 是本进程最近一次从配置文件读取或写入的内容，作为合并其他进程修改时的共同祖先。
+var baseline []byte
+
+// lockConfig 获取配置文件的跨进程锁 (同一目录下的 config.json.lock)。
+// configMutex 只能保护同一进程内的访问；读取配置文件、以及重新读取、合并并写入配置文件的过程都必须持有此锁，
+// 否则同时运行的两个 synclink (例如登录脚本中的 'relink *' 和手动执行的 'link') 会互相覆盖对方的修改。
+// 调用者必须持有 configMutex。
+func lockConfig(path string) (*util.FileLock, error) {
+	var settings Settings // 首次加载配置时只有环境变量和默认值可用
+	if configInstance != nil {
+		settings = configInstance.Settings
+	}
+	timeout := effectiveSettings(settings).LockWait()
+
+	lock, err := util.LockFile(path+".lock", timeout, func(holder string) {
+		if holder != "" {
+			util.WarningPrint("另一个 synclink 进程 (PID %s) 正在使用配置文件，等待其完成...\n", holder)
+		} else {
+			util.WarningPrint("另一个 synclink 进程正在使用配置文件，等待其完成...\n")
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("无法锁定配置文件: %w", err)
+	}
+	return lock, nil
+}
+
+// mergeFromDisk 在保存之前重新读取配置文件。如果其他进程在本进程读取之后修改了它，就把那些修改合并到 cfg 中：
+// 本进程修改过的链接和设置项以本进程为准，其余的采用文件中的最新内容。调用者必须持有配置文件锁。
+func mergeFromDisk(path string, cfg *Config) error {
+	disk, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("重新读取配置文件 '%s' 失败: %w", path, err)
+	}
+	if baseline == nil || bytes.Equal(disk, baseline) {
+		return nil // 没有其他进程修改过
+	}
+
+	// 更新版本的 synclink 写入的文件可能含有本进程不认识的字段，合并后写回会丢失它们
+	if version, err := fileVersion(disk); err == nil {
+		if _, err := pendingMigrations(version); err != nil {
+			return fmt.Errorf("配置文件 '%s' 已被其他 synclink 进程修改，拒绝覆盖: %w", path, err)
+		}
+	}
+
+	theirs, err := normalizeConfig(disk)
+	if err != nil {
+		// 无法合并，只能覆盖。先保留一份，避免丢失内容
+		util.WarningPrint("警告: 配置文件 '%s' 已被其他程序修改且无法解析，将被覆盖\n", path)
+		if _, err := backupFile(path); err != nil {
+			return fmt.Errorf("覆盖之前%w", err)
+		}
+		return nil
+	}
+	base, err := normalizeConfig(baseline)
+	if err != nil {
+		base = nil // 本进程读取的内容无法作为共同祖先时，把所有差异都视为双方的修改
+	}
+	var ours map[string]json.RawMessage
+	oursData, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("将配置序列化为 JSON 失败: %w", err)
+	}
+	if err := json.Unmarshal(oursData, &ours); err != nil {
+		return fmt.Errorf("将配置序列化为 JSON 失败: %w", err)
+	}
+
+	// 链接按名称合并，每个链接作为一个整体；设置按字段合并，sync_roots 再按名称合并
+	ours["links"] = mergeValue(base["links"], ours["links"], theirs["links"], 1)
+	ours["settings"] = mergeValue(base["settings"], ours["settings"], theirs["settings"], 2)
+
+	mergedData, err := json.Marshal(ours)
+	if err != nil {
+		return fmt.Errorf("合并配置失败: %w", err)
+	}
+	var merged Config
+	if err := json.Unmarshal(mergedData, &merged); err != nil {
+		return fmt.Errorf("合并配置失败: %w", err)
+	}
+	if merged.Links == nil {
+		merged.Links = make(map[string]LinkInfo)
+	}
+	*cfg = merged
+	return nil
+}
+
+// normalizeConfig 把配置文件内容经 Config 解码后重新编码，使手动编辑过的文件 (缺少字段、格式不同)
+// 与本进程序列化的配置可以逐个值比较，然后按顶层键拆分。
+func normalizeConfig(data []byte) (map[string]json.RawMessage, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(normalized, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mergeValue 对一个 JSON 值进行三方合并：只有一方相对 base 做了修改时采用该方的值，双方都修改时以 ours 为准。
+// depth 大于 0 且双方的值都是对象时，逐个键递归合并 (depth 减 1)。返回 nil 表示该值被删除。
+func mergeValue(base, ours, theirs json.RawMessage, depth int) json.RawMessage {
+	if equalJSON(ours, base) {
+		return theirs
+	}
+	if equalJSON(theirs, base) || equalJSON(ours, theirs) || depth == 0 {
+		return ours
+	}
+
+	var b, o, t map[string]json.RawMessage
+	if !decodeObject(base, &b) || !decodeObject(ours, &o) || !decodeObject(theirs, &t) {
+		return ours
+	}
+	result := make(map[string]json.RawMessage, len(o)+len(t))
+	for _, m := range []map[string]json.RawMessage{b, o, t} {
+		for k := range m {
+			if _, done := result[k]; !done {
+				result[k] = mergeValue(b[k], o[k], t[k], depth-1)
+			}
+		}
+	}
+	for k, v := range result {
+		if isNull(v) {
+			delete(result, k) // 被删除的键
+		}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ours
+	}
+	return data
+}
+
+// decodeObject 把 JSON 对象解码到 m 中。缺失或为 null 的值视为空对象；不是对象时返回 false。
+func decodeObject(raw json.RawMessage, m *map[string]json.RawMessage) bool {
+	if isNull(raw) {
+		*m = nil
+		return true
+	}
+	return json.Unmarshal(raw, m) == nil
+}
+
+// isNull 判断 JSON 值是否缺失或为 null。
+func isNull(raw json.RawMessage) bool {
+	return len(bytes.TrimSpace(raw)) == 0 || string(bytes.TrimSpace(raw)) == "null"
+}
+
+// equalJSON 判断两个 JSON 值在语义上是否相同 (忽略格式和对象中键的顺序，缺失与 null 视为相同)。
+func equalJSON(a, b json.RawMessage) bool {
+	if isNull(a) || isNull(b) {
+		return isNull(a) && isNull(b)
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
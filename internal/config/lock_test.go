@@ -0,0 +1,231 @@
+// internal/config/lock_test.go
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeValue(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		depth              int
+		want               string
+	}{
+		{"双方都未修改", `1`, `1`, `1`, 0, `1`},
+		{"只有本进程修改", `1`, `2`, `1`, 0, `2`},
+		{"只有其他进程修改", `1`, `1`, `3`, 0, `3`},
+		{"双方修改为相同的值", `1`, `2`, `2`, 0, `2`},
+		{"双方修改冲突时以本进程为准", `1`, `2`, `3`, 0, `2`},
+		{"depth 为 0 时对象作为整体", `{"a":1}`, `{"a":2}`, `{"a":1,"b":1}`, 0, `{"a":2}`},
+		{"按键合并", `{"a":1}`, `{"a":2}`, `{"a":1,"b":1}`, 1, `{"a":2,"b":1}`},
+		{"本进程删除键", `{"a":1,"b":1}`, `{"b":1}`, `{"a":1,"b":2}`, 1, `{"b":2}`},
+		{"其他进程删除键", `{"a":1,"b":1}`, `{"a":1,"b":2}`, `{"b":1}`, 1, `{"b":2}`},
+		{"缺失的对象视为空对象", ``, `{"a":1}`, `{"b":1}`, 1, `{"a":1,"b":1}`},
+		{"递归合并", `{"s":{"a":1}}`, `{"s":{"a":2}}`, `{"s":{"a":1,"b":1}}`, 2, `{"s":{"a":2,"b":1}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeValue(json.RawMessage(tt.base), json.RawMessage(tt.ours), json.RawMessage(tt.theirs), tt.depth)
+			if !equalJSON(got, json.RawMessage(tt.want)) {
+				t.Errorf("mergeValue = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeFromDisk(t *testing.T) {
+	const base = `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+		"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`
+
+	tests := []struct {
+		name string
+		ours string // 本进程修改后的配置
+		disk string // 其他进程在本进程加载之后写入的配置
+		want string
+	}{
+		{
+			name: "双方各自添加不同的链接",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"},
+				"a":{"original_path":"$HOME/a","synced_path":"/sync/a"}}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"},
+				"b":{"original_path":"$HOME/b","synced_path":"/sync/b"}}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"},
+				"a":{"original_path":"$HOME/a","synced_path":"/sync/a"},
+				"b":{"original_path":"$HOME/b","synced_path":"/sync/b"}}}`,
+		},
+		{
+			name: "本进程添加链接，其他进程移除另一个链接",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"},
+				"a":{"original_path":"$HOME/a","synced_path":"/sync/a"}}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"a":{"original_path":"$HOME/a","synced_path":"/sync/a"}}}`,
+		},
+		{
+			name: "本进程移除链接，其他进程修改同一个链接",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app","tags":["x"]}}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{}}`,
+		},
+		{
+			name: "其他进程移除链接，本进程添加同名链接",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/other","synced_path":"/sync/app"}}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{
+				"app":{"original_path":"$HOME/other","synced_path":"/sync/app"}}}`,
+		},
+		{
+			name: "修改不同的设置项",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/sync","lock_timeout":"5s"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/sync","verify_copy":false},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/sync","lock_timeout":"5s","verify_copy":false},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+		},
+		{
+			name: "修改同一个设置项时以本进程为准",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/ours"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/theirs"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/ours"},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+		},
+		{
+			name: "sync_roots 按名称合并",
+			ours: `{"version":"1.1","settings":{"default_sync_path":"/sync","sync_roots":{"dropbox":"/d"}},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+			disk: `{"version":"1.1","settings":{"default_sync_path":"/sync","sync_roots":{"onedrive":"/o"}},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+			want: `{"version":"1.1","settings":{"default_sync_path":"/sync","sync_roots":{"dropbox":"/d","onedrive":"/o"}},"links":{
+				"app":{"original_path":"$HOME/app","synced_path":"/sync/app"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ConfigFileName)
+			if err := os.WriteFile(path, []byte(tt.disk), 0644); err != nil {
+				t.Fatal(err)
+			}
+			setBaseline(t, []byte(base))
+
+			var cfg Config
+			if err := json.Unmarshal([]byte(tt.ours), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if err := mergeFromDisk(path, &cfg); err != nil {
+				t.Fatalf("mergeFromDisk: %v", err)
+			}
+
+			got, err := json.Marshal(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := normalizeConfig([]byte(tt.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantData, err := json.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalJSON(got, wantData) {
+				t.Errorf("合并结果:\n%s\nwant:\n%s", got, wantData)
+			}
+		})
+	}
+}
+
+func TestMergeFromDiskUnchanged(t *testing.T) {
+	data := []byte(`{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{}}`)
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	setBaseline(t, data)
+
+	cfg := Config{Settings: Settings{DefaultSyncPath: "/ours"}, Links: map[string]LinkInfo{}, Version: CurrentVersion}
+	if err := mergeFromDisk(path, &cfg); err != nil {
+		t.Fatalf("mergeFromDisk: %v", err)
+	}
+	if cfg.Settings.DefaultSyncPath != "/ours" {
+		t.Errorf("文件未被其他进程修改时不应改变本进程的配置，得到 default_sync_path = %q", cfg.Settings.DefaultSyncPath)
+	}
+}
+
+func TestSaveConfigDryRun(t *testing.T) {
+	data := []byte(`{"version":"1.1","settings":{"default_sync_path":"/sync"},"links":{}}`)
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantWritten bool
+	}{
+		{"dry-run 模式不写入", true, false},
+		{"正常模式写入", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, ConfigFileName)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			setBaseline(t, data)
+
+			prevInstance, prevDryRun, prevBackedUp := configInstance, dryRun, backedUp
+			t.Cleanup(func() { configInstance, dryRun, backedUp = prevInstance, prevDryRun, prevBackedUp })
+			configInstance = &Config{
+				Settings: Settings{DefaultSyncPath: "/changed"},
+				Links:    map[string]LinkInfo{"a": {OriginalPath: "$HOME/a", SyncedPath: "/sync/a"}},
+				Version:  CurrentVersion,
+			}
+			dryRun = tt.dryRun
+
+			if err := saveConfigTo(path); err != nil {
+				t.Fatalf("saveConfigTo: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var saved Config
+			if err := json.Unmarshal(got, &saved); err != nil {
+				t.Fatal(err)
+			}
+			if written := saved.Settings.DefaultSyncPath == "/changed"; written != tt.wantWritten {
+				t.Errorf("配置文件是否被写入: %v, want %v\n%s", written, tt.wantWritten, got)
+			}
+			if tt.dryRun {
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) != 1 {
+					t.Errorf("dry-run 模式下不应创建备份或锁文件，目录中有 %d 个文件", len(entries))
+				}
+			}
+		})
+	}
+}
+
+// setBaseline 设置合并时的共同祖先，并在测试结束后恢复。
+func setBaseline(t *testing.T, data []byte) {
+	t.Helper()
+	prev := baseline
+	baseline = data
+	t.Cleanup(func() { baseline = prev })
+}
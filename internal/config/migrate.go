@@ -0,0 +1,253 @@
+// internal/config/migrate.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"synclink/internal/util"
+)
+
+// oldestVersion 是没有 version 字段的配置文件所对应的版本。
+const oldestVersion = "1.0"
+
+// migration 把配置文件从 From 版本升级到 To 版本。迁移直接操作解码后的 JSON 文档，
+// 因此可以读取、改名或删除当前结构体中已经不存在的字段。
+type migration struct {
+	From  string
+	To    string
+	Desc  string
+	Apply func(doc map[string]any) error
+}
+
+// migrations 是按顺序排列的迁移步骤，每一步的 From 等于上一步的 To，最后一步的 To 等于 CurrentVersion。
+// 修改 LinkInfo 或 Settings 的含义 (而不只是增加可选字段) 时，需要提升 CurrentVersion 并在这里追加一步。
+var migrations = []migration{
+	{
+		From: "1.0",
+		To:   "1.1",
+		Desc: "把缺失或为 null 的 settings 和 links 补充为空对象",
+		// 1.1 新增的链接字段 (mode、files、include、exclude、tags 等) 都是可选的，缺失时的含义与 1.0 相同
+		// (整个文件或文件夹替换为一个符号链接)，因此已有的链接和设置不需要改写。
+		// 提升版本号是为了让旧版本的 synclink 拒绝加载 1.1 的文件，而不是在保存时丢弃它不认识的字段。
+		Apply: func(doc map[string]any) error {
+			for _, key := range []string{"settings", "links"} {
+				switch doc[key].(type) {
+				case map[string]any:
+				case nil:
+					doc[key] = map[string]any{}
+				default:
+					return fmt.Errorf("'%s' 不是 JSON 对象", key)
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// MigrationStep 描述一个迁移步骤，供 'config migrate' 显示。
+type MigrationStep struct {
+	From string
+	To   string
+	Desc string
+}
+
+// fileVersion 返回配置文件内容中的版本号。没有 version 字段的文件视为 oldestVersion。
+func fileVersion(data []byte) (string, error) {
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	if v.Version == "" {
+		return oldestVersion, nil
+	}
+	return v.Version, nil
+}
+
+// pendingMigrations 返回把版本 from 升级到 CurrentVersion 需要依次执行的迁移。
+// from 比 CurrentVersion 更新时返回错误：旧版本的 synclink 不能加载新版本写入的配置文件，否则保存时会丢失它不认识的字段。
+func pendingMigrations(from string) ([]migration, error) {
+	cmp, err := compareVersions(from, CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	if cmp > 0 {
+		return nil, fmt.Errorf("配置文件的版本 %s 比此 synclink 支持的版本 %s 更新，请升级 synclink", from, CurrentVersion)
+	}
+
+	var steps []migration
+	for v := from; v != CurrentVersion; {
+		i := len(steps)
+		for _, m := range migrations {
+			if m.From == v {
+				steps = append(steps, m)
+				v = m.To
+				break
+			}
+		}
+		if len(steps) == i {
+			return nil, fmt.Errorf("不支持从配置文件版本 %s 迁移到 %s", v, CurrentVersion)
+		}
+	}
+	return steps, nil
+}
+
+// migrateData 把配置文件内容升级到 CurrentVersion，返回升级后的内容、原来的版本和执行的迁移步骤。
+// 内容已是当前版本时原样返回，steps 为空。
+func migrateData(data []byte) (migrated []byte, from string, steps []migration, err error) {
+	from, err = fileVersion(data)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	steps, err = pendingMigrations(from)
+	if err != nil || len(steps) == 0 {
+		return data, from, nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, from, nil, err
+	}
+	for _, m := range steps {
+		if err := m.Apply(doc); err != nil {
+			return nil, from, nil, fmt.Errorf("将配置文件从版本 %s 迁移到 %s 失败: %w", m.From, m.To, err)
+		}
+		doc["version"] = m.To
+	}
+	migrated, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, from, nil, fmt.Errorf("将配置序列化为 JSON 失败: %w", err)
+	}
+	return migrated, from, steps, nil
+}
+
+// describeSteps 把内部的迁移步骤转换为供显示的 MigrationStep。
+func describeSteps(steps []migration) []MigrationStep {
+	result := make([]MigrationStep, 0, len(steps))
+	for _, m := range steps {
+		result = append(result, MigrationStep{From: m.From, To: m.To, Desc: m.Desc})
+	}
+	return result
+}
+
+// compareVersions 比较 "主版本.次版本" 形式的版本号，a 较旧、相同、较新时分别返回 -1、0、1。
+func compareVersions(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(v string) ([2]int, error) {
+	var parts [2]int
+	major, minor, ok := strings.Cut(v, ".")
+	if !ok {
+		return parts, fmt.Errorf("无效的配置文件版本 '%s'", v)
+	}
+	for i, s := range []string{major, minor} {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("无效的配置文件版本 '%s'", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// migrateFile 把配置文件 path 的内容 data 升级到 CurrentVersion：先备份原文件，再写入升级后的内容。
+// 返回升级后的内容；不需要迁移时原样返回 data。dry-run 模式下只在内存中迁移。调用者必须持有配置文件锁。
+func migrateFile(path string, data []byte) ([]byte, error) {
+	migrated, from, steps, err := migrateData(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return data, nil
+	}
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "[dry-run] 配置文件需要从版本 %s 迁移到 %s，本次只在内存中迁移。\n", from, CurrentVersion)
+		return migrated, nil
+	}
+
+	backup, err := backupFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("迁移之前%w", err)
+	}
+	backedUp = true // 迁移前的内容已经备份过，本进程之后的保存不再重复备份
+	if err := util.WriteFileAtomic(path, migrated, 0644); err != nil {
+		return nil, fmt.Errorf("写入迁移后的配置文件 '%s' 失败: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "已将配置文件从版本 %s 迁移到 %s，迁移前的文件已备份为 '%s'。\n", from, CurrentVersion, backup)
+	return migrated, nil
+}
+
+// CheckMigration 读取配置文件并返回它的版本，以及加载时需要执行的迁移步骤，不做任何修改。
+// 配置文件比此 synclink 支持的版本更新时返回错误。
+func CheckMigration() (version string, steps []MigrationStep, err error) {
+	cfgPath, err := getConfigPath()
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("读取配置文件 '%s' 失败: %w", cfgPath, err)
+	}
+	version, err = fileVersion(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("解析配置文件 '%s' 失败: %w。%s", cfgPath, err, restoreBackupHint)
+	}
+	pending, err := pendingMigrations(version)
+	if err != nil {
+		return version, nil, err
+	}
+	return version, describeSteps(pending), nil
+}
+
+// Migrate 立即把配置文件升级到 CurrentVersion (LoadConfig 也会自动执行)，返回执行的迁移步骤。
+func Migrate() ([]MigrationStep, error) {
+	_, steps, err := CheckMigration()
+	if err != nil || len(steps) == 0 {
+		return nil, err
+	}
+	cfgPath, _ := getConfigPath()
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	if dryRun {
+		return steps, nil
+	}
+	lock, err := lockConfig(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件 '%s' 失败: %w", cfgPath, err)
+	}
+	migrated, err := migrateFile(cfgPath, data)
+	if err != nil {
+		return nil, err
+	}
+	baseline = migrated
+	return steps, nil
+}
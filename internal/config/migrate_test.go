@@ -0,0 +1,78 @@
+// internal/config/migrate_test.go
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMigrate10KeepsLinksAndSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "没有 version 字段",
+			data: `{"settings":{"default_sync_path":"/sync","config_backups":3},"links":{
+				"uv":{"shortcut":false,"original_path":"%LOCALAPPDATA%\\uv","synced_path":"/sync/uv","created_at":"2024-01-02T03:04:05Z"},
+				"tool":{"shortcut":true,"original_path":"C:\\tool.exe","created_at":"2024-01-02T03:04:05Z"}}}`,
+		},
+		{
+			name: "version 为 1.0",
+			data: `{"version":"1.0","settings":{"default_sync_path":"/sync"},"links":{
+				"nvim":{"original_path":"$HOME/.config/nvim","synced_path":"/sync/nvim","created_at":"2024-01-02T03:04:05Z"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, from, steps, err := migrateData([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("migrateData: %v", err)
+			}
+			if from != oldestVersion || len(steps) == 0 {
+				t.Fatalf("应当从版本 %s 迁移，得到版本 %s、%d 个步骤", oldestVersion, from, len(steps))
+			}
+
+			var before, after Config
+			if err := json.Unmarshal([]byte(tt.data), &before); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(migrated, &after); err != nil {
+				t.Fatal(err)
+			}
+			if after.Version != CurrentVersion {
+				t.Errorf("迁移后的版本为 %q，want %q", after.Version, CurrentVersion)
+			}
+			if !reflect.DeepEqual(before.Links, after.Links) {
+				t.Errorf("迁移改变了链接:\n%+v\nwant:\n%+v", after.Links, before.Links)
+			}
+			if !reflect.DeepEqual(before.Settings, after.Settings) {
+				t.Errorf("迁移改变了设置:\n%+v\nwant:\n%+v", after.Settings, before.Settings)
+			}
+		})
+	}
+}
+
+func TestMigrate10FillsMissingObjects(t *testing.T) {
+	for _, data := range []string{`{}`, `{"settings":null,"links":null}`} {
+		migrated, _, _, err := migrateData([]byte(data))
+		if err != nil {
+			t.Fatalf("migrateData(%s): %v", data, err)
+		}
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(migrated, &doc); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"settings", "links"} {
+			if string(doc[key]) != "{}" {
+				t.Errorf("migrateData(%s): %s = %s, want {}", data, key, doc[key])
+			}
+		}
+	}
+
+	if _, _, _, err := migrateData([]byte(`{"links":[]}`)); err == nil {
+		t.Error("links 不是对象时迁移应当失败")
+	}
+}
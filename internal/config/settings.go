@@ -0,0 +1,348 @@
+// internal/config/settings.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"synclink/internal/util"
+)
+
+// defaultRestoreOnConflict 是未设置 restore_on_conflict 时 restore 的冲突处理策略。
+const defaultRestoreOnConflict = "abort"
+
+// SettingType 是设置项的值类型，决定 'config set' 如何校验和规范化输入。
+type SettingType string
+
+const (
+	TypeString   SettingType = "string"   // 任意字符串
+	TypePath     SettingType = "path"     // 路径，保存为绝对路径 (先展开环境变量令牌)
+	TypeBool     SettingType = "bool"     // true/false (也接受 1/0、yes/no、on/off)
+	TypeInt      SettingType = "int"      // 非负整数
+	TypeEnum     SettingType = "enum"     // Values 中的一个值
+	TypeDuration SettingType = "duration" // 正的时长，例如 30s、2m
+)
+
+// SettingDef 描述一个可以通过 'synclink config' 读写的设置项。
+// 新增设置项时，在 Settings 中添加字段 (可选的数字和布尔值使用指针，以区分未设置和零值)，
+// 然后在 settingDefs 中登记；命令行、帮助文本和环境变量覆盖都由登记的信息生成。
+type SettingDef struct {
+	Key     string
+	Type    SettingType
+	Values  []string // TypeEnum 的可选值
+	Default string   // 未设置时使用的值 (规范形式)，空字符串表示没有默认值
+	Desc    string
+
+	field func(s *Settings) any                // 返回 Settings 中对应字段的指针：*string、**int 或 **bool
+	check func(s Settings, value string) error // 可选的额外校验，value 已经过规范化且非空
+}
+
+// settingDefs 是所有设置项，按 'config list' 中显示的顺序排列。
+var settingDefs = []SettingDef{
+	{
+		Key:   "default_sync_path",
+		Type:  TypePath,
+		Desc:  "link 和 restore 未指定 -s 或 --root 时使用的同步目录",
+		field: func(s *Settings) any { return &s.DefaultSyncPath },
+	},
+	{
+		Key:   "default_sync_root",
+		Type:  TypeString,
+		Desc:  "link 和 restore 未指定 --root 或绝对路径的 -s 时使用的命名同步根目录 (见 'synclink root')",
+		field: func(s *Settings) any { return &s.DefaultSyncRoot },
+		check: func(s Settings, value string) error {
+			if _, ok := s.SyncRoots[value]; !ok {
+				return fmt.Errorf("本机未配置同步根目录 '%s'。请先使用 'synclink root set %s <path>' 设置", value, value)
+			}
+			return nil
+		},
+	},
+	{
+		Key:     "restore_on_conflict",
+		Type:    TypeEnum,
+		Values:  []string{"backup", "discard", "abort"}, // 与 link.ConflictPolicy 的取值一致
+		Default: defaultRestoreOnConflict,
+		Desc:    "restore 未指定 --on-conflict 时，原始位置已有本地副本的处理策略",
+		field:   func(s *Settings) any { return &s.RestoreOnConflict },
+	},
+	{
+		Key:     "verify_copy",
+		Type:    TypeBool,
+		Default: "true",
+		Desc:    "跨磁盘移动数据时，删除源数据之前逐个文件校验副本。关闭可以加快大量数据的移动，但无法发现复制错误",
+		field:   func(s *Settings) any { return &s.VerifyCopy },
+	},
+	{
+		Key:     "config_backups",
+		Type:    TypeInt,
+		Default: strconv.Itoa(DefaultConfigBackups),
+		Desc:    "保留的 config.json 备份数量，0 表示不保留备份 (见 'synclink config restore-backup')",
+		field:   func(s *Settings) any { return &s.ConfigBackups },
+	},
+	{
+		Key:     "lock_timeout",
+		Type:    TypeDuration,
+		Default: defaultLockTimeout.String(),
+		Desc:    "等待其他 synclink 进程释放配置文件锁的最长时间",
+		field:   func(s *Settings) any { return &s.LockTimeout },
+	},
+}
+
+// SettingDefs 返回所有设置项的描述。
+func SettingDefs() []SettingDef {
+	return slices.Clone(settingDefs)
+}
+
+// LookupSetting 按名称查找设置项 (不区分大小写)。
+func LookupSetting(key string) (SettingDef, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, d := range settingDefs {
+		if d.Key == key {
+			return d, nil
+		}
+	}
+	return SettingDef{}, fmt.Errorf("不支持的配置属性: '%s'。使用 'synclink config list' 查看所有设置项", key)
+}
+
+// EnvVar 返回覆盖该设置项的环境变量名称，例如 SYNCLINK_DEFAULT_SYNC_PATH。
+func (d SettingDef) EnvVar() string {
+	return "SYNCLINK_" + strings.ToUpper(d.Key)
+}
+
+// TypeLabel 返回用于显示的类型说明，枚举类型会列出可选值。
+func (d SettingDef) TypeLabel() string {
+	if d.Type == TypeEnum {
+		return string(d.Type) + " (" + strings.Join(d.Values, "|") + ")"
+	}
+	return string(d.Type)
+}
+
+// normalize 校验 raw 并返回它的规范形式，例如把路径转换为绝对路径、把 yes 转换为 true。
+func (d SettingDef) normalize(s Settings, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%s 的值不能为空；使用 'synclink config unset %s' 恢复默认值", d.Key, d.Key)
+	}
+
+	var value string
+	switch d.Type {
+	case TypeString:
+		value = raw
+	case TypePath:
+		abs, err := util.GetAbsPath(util.ExpandPath(raw))
+		if err != nil {
+			return "", fmt.Errorf("无效路径 '%s': %w", raw, err)
+		}
+		value = abs
+	case TypeBool:
+		b, err := parseBool(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s 必须是布尔值 (true 或 false)，而不是 '%s'", d.Key, raw)
+		}
+		value = strconv.FormatBool(b)
+	case TypeInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("%s 必须是非负整数，而不是 '%s'", d.Key, raw)
+		}
+		value = strconv.Itoa(n)
+	case TypeEnum:
+		v := strings.ToLower(raw)
+		if !slices.Contains(d.Values, v) {
+			return "", fmt.Errorf("%s 的值 '%s' 无效，只支持: %s", d.Key, raw, strings.Join(d.Values, "、"))
+		}
+		value = v
+	case TypeDuration:
+		dur, err := time.ParseDuration(raw)
+		if err != nil || dur <= 0 {
+			return "", fmt.Errorf("%s 必须是正的时长 (例如 30s、2m)，而不是 '%s'", d.Key, raw)
+		}
+		value = dur.String()
+	default:
+		return "", fmt.Errorf("内部错误：设置项 %s 的类型 '%s' 未知", d.Key, d.Type)
+	}
+
+	if d.check != nil {
+		if err := d.check(s, value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// parseBool 在 strconv.ParseBool 的基础上接受 yes/no 和 on/off。
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// stored 返回 s 中保存的值的规范形式，未设置时 ok 为 false。
+func (d SettingDef) stored(s *Settings) (value string, ok bool) {
+	switch p := d.field(s).(type) {
+	case *string:
+		return *p, *p != ""
+	case **int:
+		if *p != nil {
+			return strconv.Itoa(**p), true
+		}
+	case **bool:
+		if *p != nil {
+			return strconv.FormatBool(**p), true
+		}
+	}
+	return "", false
+}
+
+// store 把规范形式的值保存到 s 中；value 为空字符串表示删除该设置。
+func (d SettingDef) store(s *Settings, value string) {
+	switch p := d.field(s).(type) {
+	case *string:
+		*p = value
+	case **int:
+		if value == "" {
+			*p = nil
+		} else {
+			n, _ := strconv.Atoi(value)
+			*p = &n
+		}
+	case **bool:
+		if value == "" {
+			*p = nil
+		} else {
+			b, _ := strconv.ParseBool(value)
+			*p = &b
+		}
+	}
+}
+
+// SettingSource 表示设置项当前的值来自哪里。
+type SettingSource string
+
+const (
+	SourceEnv     SettingSource = "env"     // 环境变量
+	SourceConfig  SettingSource = "config"  // config.json
+	SourceDefault SettingSource = "default" // 内置默认值
+	SourceUnset   SettingSource = "unset"   // 未设置且没有默认值
+)
+
+// warnedEnv 记录已经提示过的无效环境变量，避免重复警告。
+var warnedEnv sync.Map
+
+// envOverride 返回环境变量中对设置项 d 的有效覆盖值。环境变量的值无效时打印一次警告并忽略它。
+func (d SettingDef) envOverride(s Settings) (string, bool) {
+	raw, ok := os.LookupEnv(d.EnvVar())
+	if !ok || strings.TrimSpace(raw) == "" {
+		return "", false
+	}
+	value, err := d.normalize(s, raw)
+	if err != nil {
+		if _, warned := warnedEnv.LoadOrStore(d.Key, true); !warned {
+			util.WarningPrint("警告: 忽略环境变量 %s: %v\n", d.EnvVar(), err)
+		}
+		return "", false
+	}
+	return value, true
+}
+
+// effectiveSettings 返回应用了环境变量覆盖之后的设置副本。环境变量只影响本次运行，不会被保存。
+func effectiveSettings(s Settings) Settings {
+	for _, d := range settingDefs {
+		if value, ok := d.envOverride(s); ok {
+			d.store(&s, value)
+		}
+	}
+	return s
+}
+
+// GetSetting 返回设置项的当前值 (规范形式) 及其来源：环境变量优先，其次是 config.json，最后是默认值。
+func (c *Config) GetSetting(key string) (value string, source SettingSource, err error) {
+	d, err := LookupSetting(key)
+	if err != nil {
+		return "", "", err
+	}
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	if value, ok := d.envOverride(c.Settings); ok {
+		return value, SourceEnv, nil
+	}
+	if value, ok := d.stored(&c.Settings); ok {
+		return value, SourceConfig, nil
+	}
+	if d.Default != "" {
+		return d.Default, SourceDefault, nil
+	}
+	return "", SourceUnset, nil
+}
+
+// StoredSetting 返回 config.json 中保存的值 (不考虑环境变量和默认值)，未设置时 ok 为 false。
+func (c *Config) StoredSetting(key string) (value string, ok bool, err error) {
+	d, err := LookupSetting(key)
+	if err != nil {
+		return "", false, err
+	}
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	value, ok = d.stored(&c.Settings)
+	return value, ok, nil
+}
+
+// SetSetting 校验并保存设置项，返回保存的规范形式。
+func (c *Config) SetSetting(key, raw string) (string, error) {
+	d, err := LookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+	configMutex.Lock()
+	value, err := d.normalize(c.Settings, raw)
+	if err != nil {
+		configMutex.Unlock()
+		return "", err
+	}
+	d.store(&c.Settings, value)
+	configMutex.Unlock()
+	return value, SaveConfig()
+}
+
+// UnsetSetting 从 config.json 中删除设置项，使其恢复默认值。
+func (c *Config) UnsetSetting(key string) error {
+	d, err := LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	configMutex.Lock()
+	d.store(&c.Settings, "")
+	configMutex.Unlock()
+	return SaveConfig()
+}
+
+// RestoreConflictPolicy 返回 restore 默认的冲突处理策略。
+func (s Settings) RestoreConflictPolicy() string {
+	if s.RestoreOnConflict == "" {
+		return defaultRestoreOnConflict
+	}
+	return s.RestoreOnConflict
+}
+
+// VerifyCopies 判断跨磁盘移动数据时是否校验副本。
+func (s Settings) VerifyCopies() bool {
+	return s.VerifyCopy == nil || *s.VerifyCopy
+}
+
+// LockWait 返回等待配置文件锁的最长时间。
+func (s Settings) LockWait() time.Duration {
+	if d, err := time.ParseDuration(s.LockTimeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultLockTimeout
+}
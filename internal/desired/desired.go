@@ -0,0 +1,375 @@
+// internal/desired/desired.go
+package desired
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"synclink/internal/config"
+	"synclink/internal/link"
+	"synclink/internal/util"
+)
+
+// 期望状态文件中支持的链接类型。
+const (
+	TypeSymlink  = "symlink"
+	TypeShortcut = "shortcut"
+)
+
+// Link 描述期望状态文件中的一个链接。
+type Link struct {
+	Name     string `json:"name"`                // 链接名称 (与 config.json 中的键一致)
+	Target   string `json:"target"`              // 原始位置 (符号链接) 或快捷方式的目标，可以包含环境变量令牌
+	Root     string `json:"root,omitempty"`      // 命名同步根目录，等同于 link --root
+	SyncPath string `json:"sync_path,omitempty"` // 同步目录或根目录下的子路径，等同于 link -s
+	Type     string `json:"type,omitempty"`      // symlink (默认) 或 shortcut
+
+	Mode    string   `json:"mode,omitempty"`    // 为空表示整个文件或文件夹替换为一个符号链接，tree 表示逐个文件链接 (等同于 link --tree)
+	Include []string `json:"include,omitempty"` // tree 模式的包含规则，等同于 link --include (隐含 tree)
+	Exclude []string `json:"exclude,omitempty"` // tree 模式的排除规则，等同于 link --exclude (隐含 tree)
+	Tags    []string `json:"tags,omitempty"`    // 链接的标签；省略时不修改已有链接的标签
+}
+
+// IsTree 判断期望的链接是否为 tree 模式。
+func (l Link) IsTree() bool {
+	return l.Mode == config.ModeTree
+}
+
+// File 是声明式期望状态文件的根结构体。
+type File struct {
+	Links []Link `json:"links"`
+}
+
+// Load 读取并校验期望状态文件。
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取期望状态文件 '%s' 失败: %w", path, err)
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析期望状态文件 '%s' 失败: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(f.Links))
+	for i := range f.Links {
+		l := &f.Links[i]
+		if l.Name == "" {
+			return nil, fmt.Errorf("期望状态文件 '%s' 中第 %d 个链接缺少 name", path, i+1)
+		}
+		if seen[l.Name] {
+			return nil, fmt.Errorf("期望状态文件 '%s' 中链接名称 '%s' 重复", path, l.Name)
+		}
+		seen[l.Name] = true
+		if l.Target == "" {
+			return nil, fmt.Errorf("期望状态文件 '%s' 中链接 '%s' 缺少 target", path, l.Name)
+		}
+		l.Type = strings.ToLower(l.Type)
+		if l.Type == "" {
+			l.Type = TypeSymlink
+		}
+		if l.Type != TypeSymlink && l.Type != TypeShortcut {
+			return nil, fmt.Errorf("期望状态文件 '%s' 中链接 '%s' 的类型 '%s' 无效。只支持 'symlink' 或 'shortcut'", path, l.Name, l.Type)
+		}
+		l.Mode = strings.ToLower(l.Mode)
+		if l.Mode == "" && (len(l.Include) > 0 || len(l.Exclude) > 0) {
+			l.Mode = config.ModeTree // 与 link --include/--exclude 相同
+		}
+		if l.Mode != "" && l.Mode != config.ModeTree {
+			return nil, fmt.Errorf("期望状态文件 '%s' 中链接 '%s' 的模式 '%s' 无效。只支持 'tree' 或省略", path, l.Name, l.Mode)
+		}
+		if l.IsTree() && l.Type == TypeShortcut {
+			return nil, fmt.Errorf("期望状态文件 '%s' 中链接 '%s' 是快捷方式，不能使用 tree 模式", path, l.Name)
+		}
+		if l.Tags != nil {
+			tags, err := config.NormalizeTags(l.Tags)
+			if err != nil {
+				return nil, fmt.Errorf("期望状态文件 '%s' 中链接 '%s': %w", path, l.Name, err)
+			}
+			l.Tags = tags
+		}
+	}
+	return &f, nil
+}
+
+// Action 是收敛到期望状态所需的操作类型。
+type Action string
+
+const (
+	ActionNone     Action = "none"     // 已符合期望状态
+	ActionCreate   Action = "create"   // 移动数据到同步目录并创建链接 (或创建快捷方式)
+	ActionRestore  Action = "restore"  // 同步目录中已有数据，只需在原始位置创建链接
+	ActionFix      Action = "fix"      // 配置一致，但文件系统上的链接丢失或损坏，需要重新链接
+	ActionRetag    Action = "retag"    // 链接本身符合期望，只需更新配置中的标签
+	ActionRecreate Action = "recreate" // 配置中的目标、同步位置、类型、模式或筛选规则与期望不同，需要移除后重新创建
+	ActionRemove   Action = "remove"   // 配置中存在但期望状态中没有
+	ActionConflict Action = "conflict" // 无法自动收敛，需要手动处理
+)
+
+// Change 是计划中的一项变更。
+type Change struct {
+	Name    string
+	Action  Action
+	Reason  string
+	Desired *Link // ActionRemove 时为 nil
+
+	targetPath string // 展开后的目标绝对路径
+	syncDir    string // 解析后的同步目录
+	syncRoot   string // 同步目录所属的命名同步根目录
+}
+
+// Plan 将期望状态与当前的 Config.Links 以及文件系统进行比较，返回按名称排序的变更列表。
+func Plan(cfg *config.Config, f *File) []Change {
+	var changes []Change
+	wanted := make(map[string]bool, len(f.Links))
+
+	for i := range f.Links {
+		d := &f.Links[i]
+		wanted[d.Name] = true
+		changes = append(changes, planLink(cfg, d))
+	}
+
+	for name := range cfg.GetLinks() {
+		if !wanted[name] {
+			changes = append(changes, Change{
+				Name:   name,
+				Action: ActionRemove,
+				Reason: "期望状态文件中没有该链接",
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// planLink 计算单个期望链接需要的变更。
+func planLink(cfg *config.Config, d *Link) Change {
+	c := Change{Name: d.Name, Desired: d}
+
+	targetPath, err := util.GetAbsPath(util.ExpandPath(d.Target))
+	if err != nil {
+		c.Action, c.Reason = ActionConflict, err.Error()
+		return c
+	}
+	c.targetPath = targetPath
+
+	isShortcut := d.Type == TypeShortcut
+	if !isShortcut {
+		c.syncDir, c.syncRoot, err = link.ResolveSyncBase(cfg, d.Root, d.SyncPath)
+		if err != nil {
+			c.Action, c.Reason = ActionConflict, err.Error()
+			return c
+		}
+	}
+
+	if current, exists := cfg.GetLink(d.Name); exists {
+		resolved, err := cfg.ResolveLink(current)
+		if err != nil {
+			c.Action, c.Reason = ActionConflict, err.Error()
+			return c
+		}
+		switch {
+		case resolved.Shortcut != isShortcut:
+			c.Action, c.Reason = ActionRecreate, "链接类型与期望不同"
+		case !util.SamePath(resolved.OriginalPath, targetPath):
+			c.Action, c.Reason = ActionRecreate, fmt.Sprintf("目标路径为 '%s'，期望为 '%s'", resolved.OriginalPath, targetPath)
+		case !isShortcut && !util.SamePath(resolved.SyncedPath, syncedCandidate(c.syncDir, d.Name, resolved)):
+			c.Action, c.Reason = ActionRecreate, fmt.Sprintf("同步路径为 '%s'，期望位于 '%s'", resolved.SyncedPath, c.syncDir)
+		case resolved.IsTree() != d.IsTree():
+			c.Action, c.Reason = ActionRecreate, fmt.Sprintf("链接模式为 %s，期望为 %s", modeName(resolved.IsTree()), modeName(d.IsTree()))
+		case d.IsTree() && (!slices.Equal(resolved.Include, d.Include) || !slices.Equal(resolved.Exclude, d.Exclude)):
+			c.Action, c.Reason = ActionRecreate, "tree 模式的包含或排除规则与期望不同"
+		default:
+			switch link.LinkApplyState(resolved) {
+			case link.StateApplied:
+				c.Action = ActionNone
+			case link.StatePending:
+				c.Action, c.Reason = ActionFix, "链接缺失"
+			default:
+				c.Action, c.Reason = ActionFix, "原始位置与期望不一致"
+			}
+			if c.Action == ActionNone && d.Tags != nil && !slices.Equal(resolved.Tags, d.Tags) {
+				c.Action, c.Reason = ActionRetag, fmt.Sprintf("标签为 [%s]，期望为 [%s]", strings.Join(resolved.Tags, ", "), strings.Join(d.Tags, ", "))
+			}
+		}
+		return c
+	}
+
+	if isShortcut {
+		c.Action, c.Reason = ActionCreate, "新的快捷方式"
+		return c
+	}
+
+	_, syncErr := link.FindSyncItem(c.syncDir, d.Name)
+	targetExists, _ := util.PathExists(targetPath)
+	switch {
+	case syncErr == nil && d.IsTree():
+		// tree 模式的原始位置通常是已有的真实文件夹，逐个文件的本地副本由 restore 检查
+		c.Action, c.Reason = ActionRestore, "同步目录中已有包目录"
+	case syncErr == nil && targetExists:
+		c.Action, c.Reason = ActionConflict, "原始位置和同步目录中都已有数据，请使用 'synclink restore --on-conflict' 手动选择"
+	case syncErr == nil:
+		c.Action, c.Reason = ActionRestore, "同步目录中已有数据"
+	case targetExists:
+		c.Action, c.Reason = ActionCreate, "新的符号链接"
+	default:
+		c.Action, c.Reason = ActionConflict, "原始位置和同步目录中都没有数据"
+	}
+	return c
+}
+
+// modeName 返回链接模式在计划输出中的名称。
+func modeName(tree bool) string {
+	if tree {
+		return "tree"
+	}
+	return "整体链接"
+}
+
+// syncedCandidate 返回与已有链接 current (已解析) 类型相同的期望同步路径 (文件夹或 files/ 下的文件)。
+// 类型无法确定时沿用当前的布局。
+func syncedCandidate(syncDir, name string, current config.LinkInfo) string {
+	dirCandidate := filepath.Join(syncDir, name)
+	isDir, known := link.SyncedIsDir(current)
+	if !known {
+		isDir = util.SamePath(current.SyncedPath, dirCandidate)
+	}
+	if isDir {
+		return dirCandidate
+	}
+	return filepath.Join(syncDir, "files", name)
+}
+
+// Apply 按计划依次执行变更，通过 link 包中已有的创建、重新链接和移除逻辑完成收敛。
+// prune 为 false 时跳过 ActionRemove。返回失败的变更数量。
+func Apply(changes []Change, prune bool) int {
+	failures := 0
+	for _, c := range changes {
+		var err error
+		switch c.Action {
+		case ActionNone:
+			continue
+		case ActionConflict:
+			util.ErrorPrint("[!] 跳过 '%s': %s\n", c.Name, c.Reason)
+			failures++
+			continue
+		case ActionRemove:
+			if !prune {
+				continue
+			}
+			fmt.Printf("[-] 正在移除 '%s'...\n", c.Name)
+			err = link.RemoveLinkOrShortcut(c.Name)
+		case ActionFix:
+			fmt.Printf("[~] 正在修复 '%s'...\n", c.Name)
+			if err = link.RelinkLinkOrShortcut(c.Name); err == nil {
+				err = syncTags(c)
+			}
+		case ActionRetag:
+			fmt.Printf("[~] 正在更新 '%s' 的标签...\n", c.Name)
+			err = syncTags(c)
+		case ActionRecreate:
+			fmt.Printf("[~] 正在重新创建 '%s'...\n", c.Name)
+			// 先确认移除之后可以重新创建，否则移除成功而创建失败会让链接消失
+			if err = checkRecreate(c); err == nil {
+				if err = link.RemoveLinkOrShortcut(c.Name); err == nil {
+					err = create(c)
+				}
+			}
+		case ActionCreate:
+			fmt.Printf("[+] 正在创建 '%s'...\n", c.Name)
+			err = create(c)
+		case ActionRestore:
+			fmt.Printf("[+] 正在恢复 '%s'...\n", c.Name)
+			err = restore(c)
+		}
+		if err != nil {
+			util.ErrorPrint("[!] '%s' 失败: %v\n", c.Name, err)
+			failures++
+		}
+	}
+	return failures
+}
+
+// checkRecreate 检查 ActionRecreate 在移除现有链接之后能否重新创建：
+// 移除后目标路径必须存在 (符号链接的数据会被移回它原来的位置)，新的同步位置不能被其他数据占用。
+func checkRecreate(c Change) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	current, exists := cfg.GetLink(c.Name)
+	if !exists {
+		return fmt.Errorf("链接 '%s' 未在配置中找到", c.Name)
+	}
+	resolved, err := cfg.ResolveLink(current)
+	if err != nil {
+		return err
+	}
+
+	// 现有的符号链接被移除时，数据会回到 resolved.OriginalPath
+	dataReturnsToTarget := !resolved.Shortcut && util.SamePath(resolved.OriginalPath, c.targetPath)
+	if !dataReturnsToTarget {
+		exists, err := util.PathExists(c.targetPath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("目标路径 '%s' 不存在，不能重新创建 (现有链接保持不变)", c.targetPath)
+		}
+	}
+	if c.Desired.Type == TypeShortcut {
+		return nil
+	}
+	if item, err := link.FindSyncItem(c.syncDir, c.Name); err == nil {
+		if resolved.Shortcut || !util.SamePath(item, resolved.SyncedPath) {
+			return fmt.Errorf("同步目标路径 '%s' 已存在，不能重新创建 (现有链接保持不变)", item)
+		}
+	}
+	return nil
+}
+
+// linkOptions 返回创建或恢复期望链接时使用的选项，与 link 命令行参数的含义相同。
+func linkOptions(c Change) link.LinkOptions {
+	return link.LinkOptions{
+		Shortcut: c.Desired.Type == TypeShortcut,
+		SyncRoot: c.syncRoot,
+		Tags:     c.Desired.Tags,
+		Tree:     c.Desired.IsTree(),
+		Include:  c.Desired.Include,
+		Exclude:  c.Desired.Exclude,
+	}
+}
+
+// create 为 ActionCreate 和 ActionRecreate 创建链接或快捷方式。
+func create(c Change) error {
+	return link.CreateLinkOrShortcut(c.targetPath, c.Name, c.syncDir, linkOptions(c))
+}
+
+// restore 为 ActionRestore 在原始位置创建指向同步目录中已有数据的链接。原始位置已有本地副本时失败。
+func restore(c Change) error {
+	opts := linkOptions(c)
+	if opts.Tree {
+		return link.RestoreTreeLink(c.targetPath, c.Name, c.syncDir, link.ConflictAbort, opts)
+	}
+	return link.RestoreSymbolicLink(c.targetPath, c.Name, c.syncDir, link.ConflictAbort, opts)
+}
+
+// syncTags 把已有链接的标签更新为期望状态文件中的标签。期望状态没有指定 tags 时不做任何事。
+func syncTags(c Change) error {
+	if c.Desired.Tags == nil {
+		return nil
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	_, err = cfg.SetTags(c.Name, c.Desired.Tags)
+	return err
+}
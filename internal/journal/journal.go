@@ -0,0 +1,181 @@
+// internal/journal/journal.go
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"synclink/internal/config"
+	"synclink/internal/util"
+)
+
+// DirName 是日志目录的名称，与 config.json 位于同一目录下。
+const DirName = "journal"
+
+// Op 是被记录的多步骤操作的类型。
+type Op string
+
+const (
+	OpCreate         Op = "create"          // 移动数据到同步目录 -> 创建符号链接 -> 记录配置
+	OpRemove         Op = "remove"          // 删除符号链接 -> 将数据移回 -> 移除配置
+	OpRestore        Op = "restore"         // 处理本地副本 -> 创建符号链接 -> 记录配置
+	OpRelink         Op = "relink"          // 删除错误的符号链接 -> 重新创建
+	OpCreateShortcut Op = "create-shortcut" // 创建快捷方式文件 -> 记录配置
+	OpRemoveShortcut Op = "remove-shortcut" // 删除快捷方式文件 -> 移除配置
+	OpCreateTree     Op = "create-tree"     // 逐个移动文件到包目录并创建符号链接 -> 记录配置
+	OpRemoveTree     Op = "remove-tree"     // 逐个删除文件的符号链接并将文件移回 -> 移除配置
+	OpRestoreTree    Op = "restore-tree"    // 逐个处理本地副本并创建文件的符号链接 -> 记录配置
+)
+
+// Step 是操作已经完成的最后一步。每完成一步都会先写回日志，再继续下一步。
+type Step string
+
+const (
+	StepStarted  Step = "started"  // 日志已写入，尚未修改文件系统 (或正在进行第一步)
+	StepCopied   Step = "copied"   // 跨设备移动时，完整且已校验的副本已放到 CopiedPath，源数据可以删除 (可能尚未删除完)
+	StepMoved    Step = "moved"    // 数据已移动到目标位置
+	StepUnlinked Step = "unlinked" // 原始位置的符号链接已删除
+	StepCleared  Step = "cleared"  // 原始位置的本地副本已备份或丢弃
+	StepLinked   Step = "linked"   // 符号链接 (或快捷方式文件) 已创建
+)
+
+// Entry 描述一个进行中的操作。操作开始前写入，全部完成后删除；
+// 崩溃后残留的条目由 'synclink recover' 根据 Step 和文件系统的实际状态向前完成或回滚。
+type Entry struct {
+	ID           string          `json:"id"`
+	Op           Op              `json:"op"`
+	LinkName     string          `json:"link_name"`
+	Step         Step            `json:"step"`
+	OriginalPath string          `json:"original_path"`         // 解析后的原始位置绝对路径
+	SyncedPath   string          `json:"synced_path,omitempty"` // 解析后的同步数据 (或快捷方式文件) 绝对路径
+	BackupPath   string          `json:"backup_path,omitempty"` // restore 备份本地副本时使用的路径
+	CopiedPath   string          `json:"copied_path,omitempty"` // StepCopied 时已放到位的完整副本路径 (tree 模式下是其中一个文件)
+	Policy       string          `json:"policy,omitempty"`      // restore 的冲突策略
+	Info         config.LinkInfo `json:"info"`                  // 完成后写入配置的链接信息 (保存形式)
+	StartedAt    time.Time       `json:"started_at"`
+
+	path string // 日志文件路径 (不序列化)
+}
+
+var seq atomic.Uint64 // 保证同一进程内并发操作 (例如 relink *) 的 ID 唯一
+
+// Dir 返回日志目录的路径。
+func Dir() (string, error) {
+	cfgPath, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cfgPath), DirName), nil
+}
+
+// Begin 在执行操作之前将条目写入日志，并返回该条目以便后续推进步骤。
+// 日志无法写入时返回错误，调用者不应继续执行操作。
+func Begin(e Entry) (*Entry, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := util.EnsureDirExists(dir); err != nil {
+		return nil, err
+	}
+
+	e.StartedAt = time.Now()
+	e.ID = fmt.Sprintf("%s-%d-%d", e.StartedAt.Format("20060102-150405"), os.Getpid(), seq.Add(1))
+	e.Step = StepStarted
+	e.path = filepath.Join(dir, e.ID+".json")
+	if err := e.write(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Advance 记录操作已完成 step。
+func (e *Entry) Advance(step Step) error {
+	e.Step = step
+	return e.write()
+}
+
+// MarkCopied 记录跨设备移动的完整副本已放到 dst，源数据可以删除。
+// 恢复时只有看到这个记录，才会把源位置残留的数据当作多余的副本删除。
+func (e *Entry) MarkCopied(dst string) error {
+	e.CopiedPath = dst
+	return e.Advance(StepCopied)
+}
+
+// Copied 判断日志是否记录了 dst 上的副本已完整放到位 (见 MarkCopied)。
+func (e *Entry) Copied(dst string) bool {
+	return e.Step == StepCopied && util.SamePath(e.CopiedPath, dst)
+}
+
+// Done 表示操作已全部完成 (或已完全回滚)，删除日志条目。
+func (e *Entry) Done() error {
+	if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除日志条目 '%s' 失败: %w", e.path, err)
+	}
+	return nil
+}
+
+// Path 返回日志条目文件的路径。
+func (e *Entry) Path() string {
+	return e.path
+}
+
+// write 原子地写入日志条目 (见 util.WriteFileAtomic)，保证日志文件本身不会被写坏。
+func (e *Entry) write() error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("将日志条目序列化为 JSON 失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(e.path, data, 0644); err != nil {
+		return fmt.Errorf("写入日志条目 '%s' 失败: %w", e.path, err)
+	}
+	return nil
+}
+
+// Pending 返回日志中残留的所有条目 (即被中断的操作)，按开始时间排序。
+// 日志目录不存在时返回空列表。
+func Pending() ([]*Entry, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取日志目录 '%s' 失败: %w", dir, err)
+	}
+
+	var entries []*Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue // 忽略未完成重命名的 .tmp 文件：对应的步骤尚未被记录
+		}
+		path := filepath.Join(dir, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取日志条目 '%s' 失败: %w", path, err)
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("解析日志条目 '%s' 失败: %w", path, err)
+		}
+		e.path = path
+		entries = append(entries, &e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].StartedAt.Equal(entries[j].StartedAt) {
+			return entries[i].StartedAt.Before(entries[j].StartedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
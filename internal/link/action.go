@@ -0,0 +1,141 @@
+// internal/link/action.go
+package link
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"synclink/internal/journal"
+	"synclink/internal/util"
+)
+
+// ActionKind 是一个修改步骤的类型。
+type ActionKind string
+
+const (
+	ActionMkdir     ActionKind = "mkdir"      // 创建目录 (包括缺少的父目录)
+	ActionMove      ActionKind = "move"       // 移动文件或文件夹 (跨设备时复制、校验后删除源数据)
+	ActionSymlink   ActionKind = "symlink"    // 创建符号链接
+	ActionRemove    ActionKind = "remove"     // 删除文件、符号链接或空文件夹
+	ActionRemoveAll ActionKind = "remove-all" // 删除文件夹及其全部内容
+	ActionShortcut  ActionKind = "shortcut"   // 创建、删除或修复快捷方式
+	ActionManifest  ActionKind = "manifest"   // 更新同步目录中的共享清单
+	ActionConfig    ActionKind = "config"     // 更新 config.json
+)
+
+// Action 是链接操作中的一个修改步骤。所有修改文件系统或配置的步骤都以 Action 的形式交给 perform：
+// 正常模式下立即执行；--dry-run 模式下只记录下来，因此预览的内容就是实际会执行的步骤。
+type Action struct {
+	Kind   ActionKind
+	Link   string // 所属的链接名称；与具体链接无关的配置修改为空
+	Path   string // 被操作的路径 (move 的源路径、symlink 的链接位置)
+	Target string // move 的目标路径、symlink 指向的位置
+	Desc   string // shortcut、manifest、config 类型的说明
+
+	apply   func() error   // shortcut、manifest、config 类型的实际操作
+	journal *journal.Entry // move 所属操作的日志，用于记录跨设备复制已完成 (可以为 nil)
+}
+
+// String 返回动作的中文描述。
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionMkdir:
+		return fmt.Sprintf("创建目录 '%s'", a.Path)
+	case ActionMove:
+		return fmt.Sprintf("移动 '%s' 到 '%s'", a.Path, a.Target)
+	case ActionSymlink:
+		return fmt.Sprintf("创建符号链接 '%s' -> '%s'", a.Path, a.Target)
+	case ActionRemove:
+		return fmt.Sprintf("删除 '%s'", a.Path)
+	case ActionRemoveAll:
+		return fmt.Sprintf("删除 '%s' 及其全部内容", a.Path)
+	default:
+		return a.Desc
+	}
+}
+
+var (
+	dryRun      bool
+	plannedMu   sync.Mutex // relink * 会并发处理多个链接
+	plannedActs []Action
+)
+
+// SetDryRun 开启或关闭 dry-run 模式。开启后修改步骤只会被记录，可通过 PlannedActions 取出。
+// config.json 的更新仍会在内存中执行 (调用方应同时调用 config.SetDryRun 禁止写入)，
+// 这样参数校验照常进行，同一命令中后续的步骤也能看到更新后的配置。
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// IsDryRun 返回当前是否处于 dry-run 模式。
+func IsDryRun() bool {
+	return dryRun
+}
+
+// PlannedActions 返回 dry-run 模式下记录的所有动作，按链接名称分组 (同一链接内保持执行顺序)。
+func PlannedActions() []Action {
+	plannedMu.Lock()
+	defer plannedMu.Unlock()
+	actions := append([]Action(nil), plannedActs...)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Link < actions[j].Link })
+	return actions
+}
+
+// perform 执行一个修改步骤；dry-run 模式下只记录它。
+func perform(a Action) error {
+	if dryRun {
+		if a.Kind == ActionConfig {
+			// config.json 不会被写入，但需要校验参数并更新内存中的配置
+			if err := a.apply(); err != nil {
+				return err
+			}
+		}
+		plannedMu.Lock()
+		plannedActs = append(plannedActs, a)
+		plannedMu.Unlock()
+		return nil
+	}
+
+	switch a.Kind {
+	case ActionMkdir:
+		return util.EnsureDirExists(a.Path)
+	case ActionMove:
+		return moveData(a.Path, a.Target, a.journal)
+	case ActionSymlink:
+		return os.Symlink(a.Target, a.Path)
+	case ActionRemove:
+		return os.Remove(a.Path)
+	case ActionRemoveAll:
+		return os.RemoveAll(a.Path)
+	default:
+		return a.apply()
+	}
+}
+
+// ensureDir 确保目录存在，只在目录缺失时产生 mkdir 步骤。
+func ensureDir(linkName, dir string) error {
+	if exists, _ := util.PathExists(dir); exists {
+		return nil
+	}
+	return perform(Action{Kind: ActionMkdir, Link: linkName, Path: dir})
+}
+
+// updateConfig 以 config 步骤的形式执行对 config.json 的修改。
+func updateConfig(linkName, desc string, apply func() error) error {
+	return perform(Action{Kind: ActionConfig, Link: linkName, Desc: desc, apply: apply})
+}
+
+// UpdateConfig 以 config 步骤的形式执行与具体链接无关的配置修改 (例如 'config set')，
+// 使其与链接操作一样受 dry-run 模式控制。
+func UpdateConfig(desc string, apply func() error) error {
+	return updateConfig("", desc, apply)
+}
+
+// report 打印操作进度和结果；dry-run 模式下不会执行任何操作，因此不打印。
+func report(format string, a ...any) {
+	if !dryRun {
+		fmt.Printf(format, a...)
+	}
+}
@@ -0,0 +1,136 @@
+// internal/link/doctor.go
+package link
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"synclink/internal/config"
+	"synclink/internal/util"
+)
+
+// Health 是 doctor 对一个链接的检查结果。
+type Health string
+
+const (
+	HealthOK          Health = "ok"           // 链接完好
+	HealthBroken      Health = "broken"       // 符号链接 (或快捷方式文件) 丢失、无法读取，或本机无法解析链接
+	HealthDrifted     Health = "drifted"      // 符号链接指向其他位置，或同步数据的类型与预期不符
+	HealthMissingData Health = "missing-data" // 同步数据 (或快捷方式的目标) 不存在
+	HealthConflict    Health = "conflict"     // 原始位置被不是符号链接的其他数据占用
+)
+
+// LinkHealth 是一个链接的检查结果。
+type LinkHealth struct {
+	Name   string
+	Info   config.LinkInfo // 配置中保存的原始形式
+	Status Health
+	Detail string // 状态不是 ok 时说明原因
+}
+
+// Diagnose 只读地检查配置中记录的每一个链接，结果按名称排序。
+func Diagnose(cfg *config.Config) []LinkHealth {
+	links := cfg.GetLinks()
+	result := make([]LinkHealth, 0, len(links))
+	for name, info := range links {
+		h := LinkHealth{Name: name, Info: info}
+		h.Status, h.Detail = CheckLink(cfg, info)
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// CheckLink 只读地检查一个链接 (配置中保存的原始形式)，返回状态和说明。
+func CheckLink(cfg *config.Config, info config.LinkInfo) (Health, string) {
+	resolved, err := cfg.ResolveLink(info)
+	if err != nil {
+		return HealthBroken, err.Error()
+	}
+	if info.Shortcut {
+		return checkShortcut(resolved)
+	}
+	if resolved.IsTree() {
+		return checkTree(resolved)
+	}
+	return checkSymlink(resolved)
+}
+
+// checkSymlink 检查 (已解析的) 符号链接：同步数据是否存在且类型符合预期，
+// 原始位置是否为指向同步数据的符号链接。
+func checkSymlink(info config.LinkInfo) (Health, string) {
+	if info.OriginalPath == "" || info.SyncedPath == "" {
+		return HealthBroken, "配置信息不完整 (original_path 或 synced_path 为空)"
+	}
+
+	syncedInfo, err := os.Stat(info.SyncedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return HealthMissingData, fmt.Sprintf("同步数据 '%s' 不存在", info.SyncedPath)
+		}
+		return HealthMissingData, fmt.Sprintf("无法读取同步数据 '%s': %v", info.SyncedPath, err)
+	}
+
+	if _, err := os.Lstat(info.OriginalPath); err != nil {
+		if os.IsNotExist(err) {
+			return HealthBroken, fmt.Sprintf("符号链接 '%s' 不存在", info.OriginalPath)
+		}
+		return HealthBroken, fmt.Sprintf("无法检查 '%s': %v", info.OriginalPath, err)
+	}
+	if isSymlink, _ := util.IsSymlink(info.OriginalPath); !isSymlink {
+		return HealthConflict, fmt.Sprintf("'%s' 存在但不是符号链接", info.OriginalPath)
+	}
+	target, err := os.Readlink(info.OriginalPath)
+	if err != nil {
+		return HealthBroken, fmt.Sprintf("无法读取符号链接 '%s' 的目标: %v", info.OriginalPath, err)
+	}
+	if !util.SamePath(target, info.SyncedPath) {
+		return HealthDrifted, fmt.Sprintf("符号链接指向 '%s'，而不是 '%s'", target, info.SyncedPath)
+	}
+
+	// 只与创建或恢复链接时记录的类型比较；旧版本创建的链接没有记录，不检查
+	switch info.Kind {
+	case config.KindFile:
+		if syncedInfo.IsDir() {
+			return HealthDrifted, fmt.Sprintf("同步数据 '%s' 应为文件，实际是文件夹", info.SyncedPath)
+		}
+	case config.KindDir:
+		if !syncedInfo.IsDir() {
+			return HealthDrifted, fmt.Sprintf("同步数据 '%s' 应为文件夹，实际是文件", info.SyncedPath)
+		}
+	}
+	return HealthOK, ""
+}
+
+// checkShortcut 检查 (已解析的) 快捷方式：快捷方式文件和它指向的目标是否都存在。
+func checkShortcut(info config.LinkInfo) (Health, string) {
+	if exists, _ := util.PathExists(info.OriginalPath); !exists {
+		return HealthMissingData, fmt.Sprintf("快捷方式的目标 '%s' 不存在", info.OriginalPath)
+	}
+	if exists, _ := util.PathExists(info.SyncedPath); !exists {
+		return HealthBroken, fmt.Sprintf("快捷方式文件 '%s' 不存在", info.SyncedPath)
+	}
+	return HealthOK, ""
+}
+
+// SyncedDataSize 返回链接 (配置中保存的原始形式) 的同步数据大小：文件夹为其中所有普通文件的总字节数。
+// 快捷方式没有同步数据，返回 0。
+func SyncedDataSize(cfg *config.Config, info config.LinkInfo) (int64, error) {
+	if info.Shortcut {
+		return 0, nil
+	}
+	resolved, err := cfg.ResolveLink(info)
+	if err != nil {
+		return 0, err
+	}
+	fi, err := os.Stat(resolved.SyncedPath)
+	if err != nil {
+		return 0, fmt.Errorf("无法获取同步数据 '%s' 的信息: %w", resolved.SyncedPath, err)
+	}
+	if !fi.IsDir() {
+		return fi.Size(), nil
+	}
+	size, _, err := util.DirSize(resolved.SyncedPath)
+	return size, err
+}
@@ -0,0 +1,195 @@
+// internal/link/ignore.go
+package link
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName 是 tree 模式链接的忽略规则文件。它位于被链接的文件夹 (创建链接时) 或包目录
+// (之后) 的根部，本身也会被同步，因此规则会随数据一起出现在其他机器上。
+// 文件的写法与 .gitignore 相同：每行一个排除规则，空行和以 # 开头的行被忽略，
+// 以 ! 开头的行重新包含之前的规则排除的文件，后面的规则优先。以 \# 或 \! 开头表示字面的 # 或 !。
+const IgnoreFileName = ".synclinkignore"
+
+// fileFilter 决定 tree 模式链接中哪些文件被同步。
+//
+// exclude 是按顺序排列的排除规则 (先是 --exclude，然后是忽略规则文件中的规则)，与 .gitignore 相同，
+// 匹配一个路径的最后一条规则决定它是否被排除，取反的规则 (!) 重新包含它。
+// 目录被排除时其下的全部文件都被排除，无法通过取反的规则重新包含其中的文件 (与 git 相同)。
+// include 是 --include 的规则：非空时只同步匹配其中任一规则的文件，且这些文件仍然不能被排除规则排除。
+//
+// 规则是 shell 风格的通配符 (*、?、[...])，针对使用 '/' 分隔的相对路径匹配：
+// 不含 '/' 的规则 (例如 cache 或 *.log) 匹配任意一级的文件或目录名称；
+// 含 '/' 的规则 (例如 User/globalStorage 或 /cache) 从根部开始匹配路径；
+// 以 '/' 结尾的规则 (例如 cache/) 只匹配目录，不匹配同名的文件。
+type fileFilter struct {
+	include []string
+	exclude []ignoreRule
+}
+
+// ignoreRule 是一条排除规则。negate 为 true 时，匹配的路径被重新包含。
+type ignoreRule struct {
+	pattern string
+	negate  bool
+}
+
+// loadFileFilter 合并配置中保存的规则和目录 dir 下忽略规则文件中的规则。忽略规则文件不存在时只使用前者。
+func loadFileFilter(dir string, include, exclude []string) (fileFilter, error) {
+	f := fileFilter{include: append([]string(nil), include...)}
+	for _, p := range exclude {
+		f.exclude = append(f.exclude, ignoreRule{pattern: p})
+	}
+
+	ignorePath := filepath.Join(dir, IgnoreFileName)
+	file, err := os.Open(ignorePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return f, fmt.Errorf("读取忽略规则文件 '%s' 失败: %w", ignorePath, err)
+	}
+	defer file.Close()
+
+	rules, err := parseIgnoreRules(file)
+	if err != nil {
+		return f, fmt.Errorf("忽略规则文件 '%s' %w", ignorePath, err)
+	}
+	f.exclude = append(f.exclude, rules...)
+	return f, nil
+}
+
+// parseIgnoreRules 按 .gitignore 的写法解析忽略规则文件的内容。
+func parseIgnoreRules(r io.Reader) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var rule ignoreRule
+		switch {
+		case strings.HasPrefix(text, "!"):
+			rule = ignoreRule{pattern: strings.TrimSpace(text[1:]), negate: true}
+		case strings.HasPrefix(text, "\\!"), strings.HasPrefix(text, "\\#"):
+			rule = ignoreRule{pattern: text[1:]}
+		default:
+			rule = ignoreRule{pattern: text}
+		}
+		if err := ValidatePatterns([]string{rule.pattern}); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取失败: %w", err)
+	}
+	return rules, nil
+}
+
+// ValidatePatterns 检查包含或排除规则是否为有效的通配符。
+func ValidatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		cleaned, _, _ := cleanPattern(p)
+		if cleaned == "" {
+			return fmt.Errorf("无效的规则 '%s'：规则不能为空", p)
+		}
+		if _, err := path.Match(cleaned, ""); err != nil {
+			return fmt.Errorf("无效的规则 '%s': %w", p, err)
+		}
+	}
+	return nil
+}
+
+// match 判断相对路径 rel (使用 '/' 分隔) 的普通文件是否应被同步。
+func (f fileFilter) match(rel string) bool {
+	if f.excluded(rel) {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if matchPattern(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded 按 .gitignore 的规则判断文件 rel 是否被排除：从根部开始逐级检查上级目录，
+// 任一上级目录被排除时 rel 也被排除；否则由匹配 rel 本身的最后一条规则决定。
+func (f fileFilter) excluded(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		candidate := strings.Join(parts[:i+1], "/")
+		isDir := i < len(parts)-1
+		state := false
+		for _, r := range f.exclude {
+			if matchPath(r.pattern, candidate, isDir) {
+				state = !r.negate
+			}
+		}
+		if state {
+			return true
+		}
+	}
+	return false
+}
+
+// apply 返回 files 中应被同步的文件，以及被规则排除、留在本机的文件数。
+func (f fileFilter) apply(files []string) (kept []string, excluded int) {
+	for _, rel := range files {
+		if f.match(rel) {
+			kept = append(kept, rel)
+		} else {
+			excluded++
+		}
+	}
+	return kept, excluded
+}
+
+// matchPattern 判断规则是否匹配文件 rel 本身或它的任一上级目录。
+func matchPattern(pattern, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if matchPath(pattern, strings.Join(parts[:i+1], "/"), i < len(parts)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 判断规则是否匹配相对路径 rel 本身 (不考虑上级目录)，isDir 表示 rel 是否为目录：
+// 从根部开始匹配的规则与整个路径比较，其余规则只与最后一级名称比较；只匹配目录的规则不匹配文件。
+func matchPath(pattern, rel string, isDir bool) bool {
+	pattern, anchored, dirOnly := cleanPattern(pattern)
+	if dirOnly && !isDir {
+		return false
+	}
+	candidate := rel
+	if !anchored {
+		candidate = path.Base(rel)
+	}
+	ok, _ := path.Match(pattern, candidate)
+	return ok
+}
+
+// cleanPattern 统一规则的写法 (Windows 上允许使用 '\\' 分隔)，去掉开头和结尾的 '/'，
+// 并返回规则是否从根部开始匹配 (开头或中间含有 '/')，以及是否只匹配目录 (结尾含有 '/')。
+func cleanPattern(p string) (cleaned string, anchored, dirOnly bool) {
+	p = strings.TrimSpace(p)
+	if filepath.Separator == '\\' {
+		p = strings.ReplaceAll(p, "\\", "/")
+	}
+	dirOnly = strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	anchored = strings.Contains(p, "/")
+	return strings.TrimPrefix(p, "/"), anchored, dirOnly
+}
@@ -0,0 +1,193 @@
+// internal/link/ignore_test.go
+package link
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCleanPattern(t *testing.T) {
+	type testCase struct {
+		pattern  string
+		cleaned  string
+		anchored bool
+		dirOnly  bool
+	}
+	tests := []testCase{
+		{"cache", "cache", false, false},
+		{"*.log", "*.log", false, false},
+		{" cache ", "cache", false, false},
+		{"cache/", "cache", false, true},
+		{"/cache", "cache", true, false},
+		{"/cache/", "cache", true, true},
+		{"User/globalStorage", "User/globalStorage", true, false},
+		{"User/globalStorage/", "User/globalStorage", true, true},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests,
+			testCase{`User\globalStorage`, "User/globalStorage", true, false},
+			testCase{`\cache\`, "cache", true, true},
+		)
+	} else {
+		// 其他系统上 '\' 是普通字符 (在通配符中是转义符)，不作为分隔符。
+		tests = append(tests, testCase{`User\globalStorage`, `User\globalStorage`, false, false})
+	}
+
+	for _, tt := range tests {
+		cleaned, anchored, dirOnly := cleanPattern(tt.pattern)
+		if cleaned != tt.cleaned || anchored != tt.anchored || dirOnly != tt.dirOnly {
+			t.Errorf("cleanPattern(%q) = (%q, %v, %v), want (%q, %v, %v)",
+				tt.pattern, cleaned, anchored, dirOnly, tt.cleaned, tt.anchored, tt.dirOnly)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	type testCase struct {
+		pattern string
+		rel     string
+		want    bool
+	}
+	tests := []testCase{
+		// 不含 '/' 的规则匹配任意一级的名称。
+		{"*.log", "app.log", true},
+		{"*.log", "logs/app.log", true},
+		{"*.log", "app.log.bak", false},
+		{"cache", "cache", true},
+		{"cache", "a/b/cache", true},
+		{"cache", "mycache", false},
+		// 含 '/' 的规则从根部开始匹配。
+		{"/cache", "cache", true},
+		{"/cache", "sub/cache", false},
+		{"User/globalStorage", "User/globalStorage", true},
+		{"User/globalStorage", "Other/User/globalStorage", false},
+		{"User/*.json", "User/settings.json", true},
+		{"User/*.json", "User/sub/settings.json", false},
+		// 匹配目录的规则覆盖其下的全部文件。
+		{"cache", "cache/data/blob", true},
+		{"/cache", "cache/data/blob", true},
+		{"User/globalStorage", "User/globalStorage/state.vscdb", true},
+		{"globalStorage/", "User/globalStorage/state.vscdb", true},
+		{"data", "cache/data/blob", true},
+		{"blob", "cache/data", false},
+		// 以 '/' 结尾的规则只匹配目录。
+		{"cache/", "cache", false},
+		{"cache/", "sub/cache", false},
+		{"cache/", "cache/blob", true},
+		{"cache/", "sub/cache/blob", true},
+		{"/cache/", "sub/cache/blob", false},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, testCase{`User\globalStorage`, "User/globalStorage/state.vscdb", true})
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestFileFilterMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ignore  string
+		include []string
+		exclude []string
+		want    map[string]bool
+	}{
+		{
+			name: "无规则",
+			want: map[string]bool{"a.txt": true, "cache/blob": true},
+		},
+		{
+			name:    "排除目录",
+			exclude: []string{"cache"},
+			want:    map[string]bool{"a.txt": true, "cache/blob": false, "sub/cache/blob": false},
+		},
+		{
+			name:    "包含规则作为白名单",
+			include: []string{"*.json", "User/snippets"},
+			want:    map[string]bool{"settings.json": true, "User/snippets/go.json": true, "User/snippets/a.txt": true, "a.txt": false},
+		},
+		{
+			name:    "排除规则优先于包含规则",
+			include: []string{"*.json"},
+			exclude: []string{"cache"},
+			want:    map[string]bool{"settings.json": true, "cache/state.json": false},
+		},
+		{
+			name:   "忽略规则文件中的取反规则重新包含文件",
+			ignore: "# 日志\n*.log\n!keep.log\n",
+			want:   map[string]bool{"a.log": false, "keep.log": true, "sub/keep.log": true, "a.txt": true},
+		},
+		{
+			name:    "取反规则可以重新包含 --exclude 排除的文件",
+			ignore:  "!keep.log\n",
+			exclude: []string{"*.log"},
+			want:    map[string]bool{"a.log": false, "keep.log": true},
+		},
+		{
+			name:   "后面的规则优先",
+			ignore: "!keep.log\n*.log\n",
+			want:   map[string]bool{"keep.log": false},
+		},
+		{
+			name:   "被排除的目录中的文件不能被重新包含",
+			ignore: "cache\n!cache/keep\n",
+			want:   map[string]bool{"cache/keep": false, "cache/other": false, "a.txt": true},
+		},
+		{
+			name:   "取反规则重新包含目录",
+			ignore: "/data/*\n!/data/keep\n",
+			want:   map[string]bool{"data/keep/a.txt": true, "data/tmp/a.txt": false, "data/b.txt": false},
+		},
+		{
+			name:    "只匹配目录的规则不排除同名的文件",
+			exclude: []string{"cache/"},
+			want:    map[string]bool{"cache": true, "sub/cache": true, "cache/blob": false, "sub/cache/blob": false},
+		},
+		{
+			name:   "忽略规则文件中只匹配目录的规则",
+			ignore: "logs/\n!keep/logs\n",
+			want:   map[string]bool{"logs": true, "keep/logs": true, "logs/a.log": false, "sub/logs/a.log": false, "keep/logs/a.log": true},
+		},
+		{
+			name:   "转义的 ! 和 #",
+			ignore: "\\!important\n\\#notes\n",
+			want:   map[string]bool{"!important": false, "#notes": false, "important": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.ignore != "" {
+				if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(tt.ignore), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			f, err := loadFileFilter(dir, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("loadFileFilter: %v", err)
+			}
+			for rel, want := range tt.want {
+				if got := f.match(rel); got != want {
+					t.Errorf("match(%q) = %v, want %v", rel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFileFilterInvalidRule(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("*.log\n[abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadFileFilter(dir, nil, nil); err == nil {
+		t.Fatal("loadFileFilter 应当拒绝无效的规则")
+	}
+}
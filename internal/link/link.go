@@ -6,20 +6,61 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"time"
 
 	"synclink/internal/config"
+	"synclink/internal/journal"
+	"synclink/internal/progress"
 	"synclink/internal/util"
 )
 
+// LinkOptions 控制创建链接时的可选行为。
+type LinkOptions struct {
+	Shortcut     bool     // 创建快捷方式而不是符号链接 (RestoreSymbolicLink 忽略此字段)
+	AbsolutePath bool     // 在配置中保存绝对路径，而不是带环境变量令牌的可移植路径
+	SyncRoot     string   // syncDir 所在的命名同步根目录；非空时 SyncedPath 以相对于该根目录的形式保存
+	Tags         []string // 记录到配置中的标签 (应已通过 config.NormalizeTags 整理)
+	Tree         bool     // 以 tree 模式逐个链接文件夹中的文件，而不是用一个符号链接替换整个文件夹
+	Files        []string // tree 模式下要链接的文件或子文件夹 (相对于目标路径)；为空表示全部文件
+	Include      []string // tree 模式的包含规则 (见 fileFilter)，保存到配置中
+	Exclude      []string // tree 模式的排除规则 (见 fileFilter)，保存到配置中
+}
+
+// storedOriginalPath 返回写入配置的原始路径：默认将用户目录前缀替换为环境变量令牌，
+// 使配置在其他机器或账户上也能使用。
+func storedOriginalPath(absPath string, opts LinkOptions) string {
+	if opts.AbsolutePath {
+		return absPath
+	}
+	return util.ContractPath(absPath)
+}
+
+// storedSyncedPath 返回写入配置的同步路径：指定了命名同步根目录时保存相对于根目录的路径，
+// 这样同一份配置在同步根目录位于不同位置的机器上仍然有效。
+func storedSyncedPath(cfg *config.Config, syncedPath string, opts LinkOptions) (string, error) {
+	if opts.SyncRoot == "" {
+		return syncedPath, nil
+	}
+	root, err := cfg.GetSyncRoot(opts.SyncRoot)
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(root, syncedPath) {
+		return "", fmt.Errorf("同步路径 '%s' 不在同步根目录 '%s' ('%s') 之下", syncedPath, opts.SyncRoot, root)
+	}
+	return filepath.Rel(root, syncedPath)
+}
+
 // CreateSymbolicLink 处理创建符号链接的逻辑：
 // 1. 将 targetPath 移动到 syncDir 下。
 // 2. 在 targetPath 的原始位置创建指向新位置的符号链接。
 // 3. 将链接信息添加到配置中。
+// 每一步之前都会写入操作日志，崩溃后可通过 'synclink recover' 完成或回滚。
 // targetPath: 用户指定的需要被链接的原始文件或文件夹路径。
 // linkName: 用户为这个链接指定的名称 (用于配置和 syncDir 中的命名)。
 // syncDir: 同步目录的基础路径 (例如 config.Settings.DefaultSyncPath)。
-func CreateSymbolicLink(targetPath, linkName, syncDir string) error {
+func CreateSymbolicLink(targetPath, linkName, syncDir string, opts LinkOptions) error {
 	cfg, err := config.GetConfig()
 	if err != nil {
 		return err
@@ -47,75 +88,191 @@ func CreateSymbolicLink(targetPath, linkName, syncDir string) error {
 	isFile, _ := util.IsFile(absTargetPath)
 
 	// --- 计算同步路径 ---
-	var syncedPath string
-	if isFile {
-		// 文件存储在 syncDir/files/linkName 下
-		filesDir := filepath.Join(syncDir, "files")
-		if err := util.EnsureDirExists(filesDir); err != nil {
-			return err
-		}
-		syncedPath = filepath.Join(filesDir, linkName) // 使用 linkName 作为文件名
-	} else if isDir {
-		// 文件夹存储在 syncDir/linkName 下
-		syncedPath = filepath.Join(syncDir, linkName)
-		// 检查目标 syncPath 是否已存在内容，避免意外覆盖
-		syncPathExists, _ := util.PathExists(syncedPath)
-		if syncPathExists {
-			// 可以选择返回错误，或者提供覆盖选项（当前返回错误）
-			return fmt.Errorf("同步目标路径 '%s' 已存在", syncedPath)
-		}
-		// 确保 syncPath 的父目录存在 (虽然 Join 通常不需要，但 EnsureDirExists 更安全)
-		if err := util.EnsureDirExists(filepath.Dir(syncedPath)); err != nil {
-			return fmt.Errorf("无法创建同步目录的父目录 '%s': %w", filepath.Dir(syncedPath), err)
-		}
-	} else {
+	syncedPath := syncedPathFor(syncDir, linkName, isDir)
+	if !isFile && !isDir {
 		// 既不是文件也不是目录（可能是特殊文件、损坏的链接等），不支持
 		return fmt.Errorf("目标路径 '%s' 不是常规文件或目录，不支持链接", absTargetPath)
 	}
+	// 文件夹存储在 syncDir/linkName 下，文件存储在 syncDir/files/linkName 下
+	// 检查目标 syncPath 是否已存在内容，避免意外覆盖
+	if syncPathExists, _ := lexists(syncedPath); syncPathExists {
+		return fmt.Errorf("同步目标路径 '%s' 已存在", syncedPath)
+	}
+	// 在移动数据之前确定配置中保存的形式，避免移动后才发现同步根目录无效
+	storedSynced, err := storedSyncedPath(cfg, syncedPath, opts)
+	if err != nil {
+		return err
+	}
+	linkInfo := config.LinkInfo{
+		Shortcut:     false, // 明确标记为非快捷方式
+		OriginalPath: storedOriginalPath(absTargetPath, opts),
+		SyncedPath:   storedSynced,
+		SyncRoot:     opts.SyncRoot,
+		Kind:         kindOf(isDir),
+		CreatedAt:    time.Now(),
+		Tags:         opts.Tags,
+	}
+
+	// 先写入操作日志：之后任何一步被中断，'synclink recover' 都能据此完成或回滚
+	j, err := beginOp(journal.Entry{
+		Op:           journal.OpCreate,
+		LinkName:     linkName,
+		OriginalPath: absTargetPath,
+		SyncedPath:   syncedPath,
+		Info:         linkInfo,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 确保 syncPath 的父目录存在
+	if err := ensureDir(linkName, filepath.Dir(syncedPath)); err != nil {
+		finishOp(j)
+		return fmt.Errorf("无法创建同步目录的父目录 '%s': %w", filepath.Dir(syncedPath), err)
+	}
 
 	// --- 执行移动和链接 ---
-	fmt.Printf("正在移动 '%s' 到 '%s'...\n", absTargetPath, syncedPath)
-	// 注意：MoveFileOrDir 的基础实现可能没有跨磁盘进度条
-	if err := util.MoveFileOrDir(absTargetPath, syncedPath); err != nil {
-		// 尝试清理：如果 syncedPath 被部分创建，可能需要删除
-		// os.RemoveAll(syncedPath) // 可选的清理步骤
+	report("正在移动 '%s' 到 '%s'...\n", absTargetPath, syncedPath)
+	if err := perform(Action{Kind: ActionMove, Link: linkName, Path: absTargetPath, Target: syncedPath, journal: j}); err != nil {
+		// 跨设备移动时，复制完成后删除源数据失败会使两处都有数据，交给 recover 处理
+		if syncedExists, _ := lexists(syncedPath); syncedExists {
+			return fmt.Errorf("移动 '%s' 到 '%s' 失败: %w。%s", absTargetPath, syncedPath, err, errRunRecover)
+		}
+		finishOp(j)
 		return fmt.Errorf("移动 '%s' 到 '%s' 失败: %w", absTargetPath, syncedPath, err)
 	}
+	advanceOp(j, journal.StepMoved)
 
-	fmt.Printf("正在创建符号链接 '%s' -> '%s'...\n", absTargetPath, syncedPath)
-	if err := os.Symlink(syncedPath, absTargetPath); err != nil {
+	report("正在创建符号链接 '%s' -> '%s'...\n", absTargetPath, syncedPath)
+	if err := perform(Action{Kind: ActionSymlink, Link: linkName, Path: absTargetPath, Target: syncedPath}); err != nil {
 		// 尝试回滚移动操作
-		if errMoveBack := util.MoveFileOrDir(syncedPath, absTargetPath); errMoveBack != nil {
+		if errMoveBack := moveData(syncedPath, absTargetPath, nil); errMoveBack != nil {
 			util.WarningPrint("回滚移动操作失败！ '%s' 可能需要手动恢复到 '%s'。%v\n",
 				syncedPath, absTargetPath, errMoveBack)
+			return fmt.Errorf("创建符号链接 '%s' 失败: %w。%s", absTargetPath, err, errRunRecover)
 		}
+		finishOp(j)
 		return fmt.Errorf("创建符号链接 '%s' 失败: %w", absTargetPath, err)
 	}
+	advanceOp(j, journal.StepLinked)
 
 	// --- 更新配置 ---
-	linkInfo := config.LinkInfo{
-		Shortcut:     false, // 明确标记为非快捷方式
-		OriginalPath: absTargetPath,
-		SyncedPath:   syncedPath,
-		CreatedAt:    time.Now(),
+	if err := addLinkToConfig(cfg, linkName, linkInfo); err != nil {
+		// 物理链接已创建，但配置未保存：保留日志，由 recover 补充配置记录
+		return fmt.Errorf("链接已创建 '%s'，但保存配置失败: %w。%s", linkName, err, errRunRecover)
 	}
 
-	if err := cfg.AddLink(linkName, linkInfo); err != nil {
-		// 严重问题：操作已完成但配置未保存
-		// 尝试清理（删除链接，移回文件），但这很复杂且风险高
-		// 更好的做法是通知用户手动检查
-		util.WarningPrint("物理链接已创建，但更新配置文件失败！请手动检查 config.json。错误: %v", err)
-		return fmt.Errorf("链接已创建 '%s'，但保存配置失败: %w", linkName, err)
-	}
+	recordInManifest(cfg, linkName, linkInfo)
+	finishOp(j)
 
-	fmt.Printf("成功创建并记录符号链接 '%s'.\n", linkName)
+	report("成功创建并记录符号链接 '%s'.\n", linkName)
 	return nil
 }
 
+// syncedPathFor 计算链接数据在同步目录中的存放位置：
+// 文件夹存放在 syncDir/linkName，文件存放在 syncDir/files/linkName。
+func syncedPathFor(syncDir, linkName string, isDir bool) string {
+	if isDir {
+		return filepath.Join(syncDir, linkName)
+	}
+	return filepath.Join(syncDir, "files", linkName)
+}
+
+// kindOf 返回同步数据的类型在 LinkInfo.Kind 中记录的形式。
+func kindOf(isDir bool) string {
+	if isDir {
+		return config.KindDir
+	}
+	return config.KindFile
+}
+
+// SyncedIsDir 判断 (已解析的) 符号链接的同步数据是文件夹还是文件。优先使用配置中记录的类型；
+// 旧版本创建的链接没有记录时，检查同步数据或原始位置上的实际数据。都无法确定时 known 为 false。
+func SyncedIsDir(info config.LinkInfo) (isDir, known bool) {
+	switch {
+	case info.IsTree():
+		return true, true
+	case info.Kind != "":
+		return info.Kind == config.KindDir, true
+	}
+	for _, p := range []string{info.SyncedPath, info.OriginalPath} {
+		if p == "" {
+			continue
+		}
+		if fi, err := os.Stat(p); err == nil {
+			return fi.IsDir(), true
+		}
+	}
+	return false, false
+}
+
+// addLinkToConfig 以 config 步骤的形式将链接记录到配置中。
+func addLinkToConfig(cfg *config.Config, linkName string, info config.LinkInfo) error {
+	return updateConfig(linkName, fmt.Sprintf("在配置中记录链接 '%s'", linkName), func() error {
+		return cfg.AddLink(linkName, info)
+	})
+}
+
+// removeLinkFromConfig 以 config 步骤的形式从配置中移除链接。
+func removeLinkFromConfig(cfg *config.Config, linkName string) (removed bool, err error) {
+	err = updateConfig(linkName, fmt.Sprintf("从配置中移除链接 '%s'", linkName), func() error {
+		removed, err = cfg.RemoveLink(linkName)
+		return err
+	})
+	return removed, err
+}
+
+// moveData 移动数据；跨设备移动需要复制时，在终端上显示进度条。
+// j 不为 nil 时，完整的副本放到 dst 之后、删除源数据之前会在操作日志中记录 (见 journal.Entry.MarkCopied)。
+func moveData(src, dst string, j *journal.Entry) error {
+	bar := progress.New("正在复制")
+	defer bar.Finish()
+	var onCopied func()
+	if j != nil {
+		onCopied = func() { markCopied(j, dst) }
+	}
+	return util.MoveFileOrDirNotify(src, dst, bar.Update, onCopied)
+}
+
+// ResolveSyncBase 根据 --root 和 -s 参数确定存放数据的同步目录，以及它所属的命名同步根目录。
+// 未指定 --root 时，若配置了 default_sync_root，则相对的 -s (或未指定 -s) 都落在该根目录下；
+// 绝对的 -s 总是按原样使用。两者都未配置时回退到 default_sync_path。
+func ResolveSyncBase(cfg *config.Config, rootName, syncPathFlag string) (syncDir, syncRoot string, err error) {
+	settings := cfg.GetSettings()
+	absFlag := filepath.IsAbs(util.ExpandPath(syncPathFlag)) // 以令牌开头的 -s (例如 $HOME/x) 也是绝对路径
+	if rootName == "" && !absFlag {
+		rootName = settings.DefaultSyncRoot
+	}
+	if rootName == "" && syncPathFlag == "" {
+		if settings.DefaultSyncPath == "" {
+			return "", "", fmt.Errorf("未指定同步路径 (-s)，且配置中未设置默认同步路径，请使用 'synclink config set default_sync_path <路径>' 设置。")
+		}
+		return settings.DefaultSyncPath, "", nil
+	}
+
+	if rootName == "" {
+		syncDir, err = util.GetAbsPath(util.ExpandPath(syncPathFlag))
+		return syncDir, "", err
+	}
+
+	rootPath, err := cfg.GetSyncRoot(rootName)
+	if err != nil {
+		return "", "", err
+	}
+	if syncPathFlag == "" {
+		return rootPath, rootName, nil
+	}
+	if absFlag {
+		return "", "", fmt.Errorf("与 --root 一起使用时，-s 必须是相对于同步根目录的路径，而不是 '%s'", syncPathFlag)
+	}
+	return filepath.Join(rootPath, syncPathFlag), rootName, nil
+}
+
 // RemoveSymbolicLink 处理移除符号链接的逻辑：
 // 1. 删除 originalPath 处的符号链接。
 // 2. 将 syncedPath 的内容移回 originalPath。
 // 3. 从配置中移除链接信息。
+// 每一步之前都会写入操作日志，崩溃后可通过 'synclink recover' 完成移除。
 // linkName: 要移除的链接的名称。
 func RemoveSymbolicLink(linkName string) error {
 	cfg, err := config.GetConfig()
@@ -127,10 +284,18 @@ func RemoveSymbolicLink(linkName string) error {
 	if !exists {
 		return fmt.Errorf("链接 '%s' 未在配置中找到", linkName)
 	}
+	storedInfo := linkInfo                    // 配置中保存的原始形式，用于更新共享清单
+	linkInfo, err = cfg.ResolveLink(linkInfo) // 展开环境变量令牌并解析同步根目录
+	if err != nil {
+		return err
+	}
 
 	if linkInfo.Shortcut {
 		return fmt.Errorf("链接 '%s' 是一个快捷方式，请使用 unlink shortcut 命令（或确保逻辑分离）", linkName)
 	}
+	if linkInfo.IsTree() {
+		return removeTreeLink(cfg, linkName, storedInfo, linkInfo)
+	}
 
 	if linkInfo.OriginalPath == "" || linkInfo.SyncedPath == "" {
 		// 数据不完整，可能配置已损坏
@@ -162,14 +327,27 @@ func RemoveSymbolicLink(linkName string) error {
 		// return fmt.Errorf("同步路径 '%s' 不存在，无法恢复原始文件/文件夹", linkInfo.SyncedPath) // 更严格的选择
 	}
 
+	// 先写入操作日志：之后任何一步被中断，'synclink recover' 都能据此完成移除
+	j, err := beginOp(journal.Entry{
+		Op:           journal.OpRemove,
+		LinkName:     linkName,
+		OriginalPath: linkInfo.OriginalPath,
+		SyncedPath:   linkInfo.SyncedPath,
+		Info:         storedInfo,
+	})
+	if err != nil {
+		return err
+	}
+
 	// --- 执行移除和移动 ---
 	if isSymlink { // 仅当原始位置确实是符号链接时才删除
-		if err := os.Remove(linkInfo.OriginalPath); err != nil {
+		if err := perform(Action{Kind: ActionRemove, Link: linkName, Path: linkInfo.OriginalPath}); err != nil {
 			// 如果删除失败，可能不应该继续移动，因为原始位置可能被占用
+			finishOp(j)
 			return fmt.Errorf("删除符号链接 '%s' 失败: %w", linkInfo.OriginalPath, err)
 		}
 	} else if originalExists {
-		fmt.Printf("跳过删除 '%s'，因为它不是符号链接。\n", linkInfo.OriginalPath)
+		report("跳过删除 '%s'，因为它不是符号链接。\n", linkInfo.OriginalPath)
 		// 如果原始路径存在但不是链接，移动操作可能会失败或覆盖用户文件！
 		// 增加检查，如果原始路径存在且非空，则中止移动。
 		isEmpty := true
@@ -184,36 +362,43 @@ func RemoveSymbolicLink(linkName string) error {
 		if !isEmpty {
 			errMsg := fmt.Sprintf("原始路径 '%s' 存在且非空，并且不是预期的符号链接。为防止数据丢失，取消将 '%s' 移回的操作。请手动处理。", linkInfo.OriginalPath, linkInfo.SyncedPath)
 			// 仍然尝试删除配置记录
-			_, removeErr := cfg.RemoveLink(linkName)
+			_, removeErr := removeLinkFromConfig(cfg, linkName)
 			if removeErr != nil {
 				errMsg += fmt.Sprintf(" (移除配置记录也失败: %v)", removeErr)
 			} else {
 				errMsg += " (配置记录已移除)"
 			}
+			finishOp(j)
 			return errors.New(errMsg)
 		}
-		fmt.Printf("原始路径 '%s' 存在但非符号链接，且为空，将尝试移动内容...\n", linkInfo.OriginalPath)
+		report("原始路径 '%s' 存在但非符号链接，且为空，将尝试移动内容...\n", linkInfo.OriginalPath)
 	}
+	advanceOp(j, journal.StepUnlinked)
 
 	if syncedExists {
-		if err := util.MoveFileOrDir(linkInfo.SyncedPath, linkInfo.OriginalPath); err != nil {
+		if err := perform(Action{Kind: ActionMove, Link: linkName, Path: linkInfo.SyncedPath, Target: linkInfo.OriginalPath, journal: j}); err != nil {
 			// 移动失败，这也很麻烦
 			// 此时符号链接（如果存在且被删除）已删除，但数据仍在同步位置
-			return fmt.Errorf("无法将 '%s' 移回 '%s': %w。请手动恢复。", linkInfo.SyncedPath, linkInfo.OriginalPath, err)
+			return fmt.Errorf("无法将 '%s' 移回 '%s': %w。%s", linkInfo.SyncedPath, linkInfo.OriginalPath, err, errRunRecover)
 		}
+		advanceOp(j, journal.StepMoved)
 	} else {
 		util.WarningPrint("跳过移回操作，因为同步路径 '%s' 不存在。", linkInfo.SyncedPath)
 	}
 
 	// --- 更新配置 ---
-	removed, err := cfg.RemoveLink(linkName)
+	// 数据已离开同步目录，其他机器也不应再应用这个链接
+	removeFromManifest(cfg, linkName, storedInfo)
+
+	removed, err := removeLinkFromConfig(cfg, linkName)
 	if err != nil {
-		return fmt.Errorf("物理文件/链接已处理，但从配置中移除 '%s' 失败: %w", linkName, err)
+		return fmt.Errorf("物理文件/链接已处理，但从配置中移除 '%s' 失败: %w。%s", linkName, err, errRunRecover)
 	}
 	if !removed {
 		// 这理论上不应该发生，因为我们开始时检查了 exists
 		util.WarningPrint("尝试移除链接 '%s'，但配置中似乎已不存在。", linkName)
 	}
+	finishOp(j)
 	return nil
 }
 
@@ -229,18 +414,29 @@ func RelinkSymbolicLink(linkName string) error {
 	if !exists {
 		return fmt.Errorf("链接 '%s' 未在配置中找到", linkName)
 	}
+	linkInfo, err = cfg.ResolveLink(linkInfo) // 展开环境变量令牌并解析同步根目录
+	if err != nil {
+		return err
+	}
 
 	if linkInfo.Shortcut {
 		return fmt.Errorf("链接 '%s' 是一个快捷方式，请使用 relink shortcut 命令（或确保逻辑分离）", linkName)
 	}
+	if linkInfo.IsTree() {
+		return relinkTreeLink(cfg, linkName, linkInfo)
+	}
 
 	if linkInfo.OriginalPath == "" || linkInfo.SyncedPath == "" {
 		return fmt.Errorf("链接 '%s' 的配置信息不完整", linkName)
 	}
 
 	// --- 检查当前状态 ---
-	originalExists, _ := util.PathExists(linkInfo.OriginalPath)
+	// 使用 Lstat 而不是 PathExists：同步根目录移动后，旧链接会变成悬空链接，
+	// PathExists 会跟随链接并误报为不存在，导致随后创建链接时因路径已被占用而失败。
+	_, lstatErr := os.Lstat(linkInfo.OriginalPath)
+	originalExists := lstatErr == nil
 	needsRelink := false
+	staleLink := "" // 需要先删除的旧链接的描述 (损坏的 / 指向错误的)，为空表示不需要删除
 
 	if !originalExists {
 		fmt.Printf("符号链接 '%s' 不存在，需要重新创建。\n", linkInfo.OriginalPath)
@@ -256,17 +452,13 @@ func RelinkSymbolicLink(linkName string) error {
 			if err != nil {
 				// 读取链接目标失败，可能链接损坏
 				util.WarningPrint("无法读取符号链接 '%s' 的目标: %v。将尝试重新创建。", linkInfo.OriginalPath, err)
-				// 尝试删除损坏的链接
-				if errRem := os.Remove(linkInfo.OriginalPath); errRem != nil {
-					return fmt.Errorf("无法移除损坏的符号链接 '%s'，重新链接失败: %w", linkInfo.OriginalPath, errRem)
-				}
+				// 稍后删除损坏的链接
+				staleLink = "损坏的"
 				needsRelink = true
 			} else if currentTarget != linkInfo.SyncedPath {
 				fmt.Printf("符号链接 '%s' 指向 '%s' 而不是预期的 '%s'。将尝试修正。\n", linkInfo.OriginalPath, currentTarget, linkInfo.SyncedPath)
-				// 删除错误的链接
-				if errRem := os.Remove(linkInfo.OriginalPath); errRem != nil {
-					return fmt.Errorf("无法移除指向错误的符号链接 '%s'，重新链接失败: %w", linkInfo.OriginalPath, errRem)
-				}
+				// 稍后删除错误的链接
+				staleLink = "指向错误的"
 				needsRelink = true
 			} else {
 				// 链接存在且正确
@@ -278,17 +470,35 @@ func RelinkSymbolicLink(linkName string) error {
 
 	// --- 如果需要，执行重新链接 ---
 	if needsRelink {
+		j, err := beginOp(journal.Entry{
+			Op:           journal.OpRelink,
+			LinkName:     linkName,
+			OriginalPath: linkInfo.OriginalPath,
+			SyncedPath:   linkInfo.SyncedPath,
+		})
+		if err != nil {
+			return err
+		}
+		defer finishOp(j) // 中断时原始位置最多只是缺少链接，再次 relink 即可修复，因此失败时也不保留日志
+
+		if staleLink != "" {
+			if errRem := perform(Action{Kind: ActionRemove, Link: linkName, Path: linkInfo.OriginalPath}); errRem != nil {
+				return fmt.Errorf("无法移除%s符号链接 '%s'，重新链接失败: %w", staleLink, linkInfo.OriginalPath, errRem)
+			}
+			advanceOp(j, journal.StepUnlinked)
+		}
+
 		// 在重新创建链接之前，必须确保同步目标仍然存在
 		syncedExists, _ := util.PathExists(linkInfo.SyncedPath)
 		if !syncedExists {
 			return fmt.Errorf("同步路径 '%s' 不存在，无法重新创建链接 '%s'", linkInfo.SyncedPath, linkInfo.OriginalPath)
 		}
 
-		fmt.Printf("正在重新创建符号链接 '%s' -> '%s'...\n", linkInfo.OriginalPath, linkInfo.SyncedPath)
-		if err := os.Symlink(linkInfo.SyncedPath, linkInfo.OriginalPath); err != nil {
+		report("正在重新创建符号链接 '%s' -> '%s'...\n", linkInfo.OriginalPath, linkInfo.SyncedPath)
+		if err := perform(Action{Kind: ActionSymlink, Link: linkName, Path: linkInfo.OriginalPath, Target: linkInfo.SyncedPath}); err != nil {
 			return fmt.Errorf("重新创建符号链接 '%s' 失败: %w", linkInfo.OriginalPath, err)
 		}
-		fmt.Println("符号链接重新创建成功.")
+		report("符号链接重新创建成功.\n")
 	}
 
 	return nil
@@ -317,11 +527,14 @@ var RelinkShortcutDelegate func(linkName, startMenuPathBase string, linkInfo con
 // GetStartMenuProgramsPathDelegate 获取特定平台的开始菜单程序路径。
 var GetStartMenuProgramsPathDelegate func() (string, error)
 
+// ErrShortcutUnsupported 表示当前平台没有可用的快捷方式实现 (委托未被赋值)。
+var ErrShortcutUnsupported = fmt.Errorf("当前平台 (%s) 暂不支持快捷方式功能", runtime.GOOS)
+
 // CreateLinkOrShortcut 根据参数决定是创建符号链接还是快捷方式。
-func CreateLinkOrShortcut(targetPath, linkName, syncPathBase string, isShortcut bool) error {
-	if isShortcut {
+func CreateLinkOrShortcut(targetPath, linkName, syncPathBase string, opts LinkOptions) error {
+	if opts.Shortcut {
 		if CreateShortcutDelegate == nil || GetStartMenuProgramsPathDelegate == nil {
-			return errors.New("创建快捷方式的功能在此系统上不受支持或未正确初始化")
+			return fmt.Errorf("无法创建快捷方式 '%s': %w", linkName, ErrShortcutUnsupported)
 		}
 		startMenuPath, err := GetStartMenuProgramsPathDelegate()
 		if err != nil {
@@ -342,39 +555,70 @@ func CreateLinkOrShortcut(targetPath, linkName, syncPathBase string, isShortcut
 			return fmt.Errorf("快捷方式的目标路径 '%s' 不存在", absTargetPath)
 		}
 
-		// 调用特定平台的实现来创建快捷方式物理文件
-		shortcutFilePath, err := CreateShortcutDelegate(absTargetPath, linkName, startMenuPath)
-		if err != nil {
-			return err // CreateShortcutDelegate 应返回具体的错误信息
-		}
-
-		// 更新配置
 		cfg, err := config.GetConfig()
 		if err != nil {
-			// 物理快捷方式已创建，但配置失败，需要警告
-			util.WarningPrint("快捷方式文件 '%s' 已创建，但加载/保存配置失败: %v。请手动检查配置。\n", shortcutFilePath, err)
-			return fmt.Errorf("快捷方式 '%s' 已创建，但处理配置失败: %w", linkName, err)
+			return err
 		}
 
 		linkInfo := config.LinkInfo{
 			Shortcut:     true,
-			OriginalPath: absTargetPath,    // 快捷方式的目标
-			SyncedPath:   shortcutFilePath, // 对于快捷方式，我们将 SyncedPath 用于存储 .lnk 文件的路径
+			OriginalPath: storedOriginalPath(absTargetPath, opts), // 快捷方式的目标
 			CreatedAt:    time.Now(),
+			Tags:         opts.Tags,
+		}
+		j, err := beginOp(journal.Entry{
+			Op:           journal.OpCreateShortcut,
+			LinkName:     linkName,
+			OriginalPath: absTargetPath,
+			Info:         linkInfo,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 调用特定平台的实现来创建快捷方式物理文件
+		var shortcutFilePath string
+		err = perform(Action{
+			Kind: ActionShortcut,
+			Link: linkName,
+			Desc: fmt.Sprintf("在 '%s' 中创建指向 '%s' 的快捷方式", startMenuPath, absTargetPath),
+			apply: func() (err error) {
+				shortcutFilePath, err = CreateShortcutDelegate(absTargetPath, linkName, startMenuPath)
+				return err
+			},
+		})
+		if err != nil {
+			finishOp(j)
+			return err // CreateShortcutDelegate 应返回具体的错误信息
 		}
 
-		if err := cfg.AddLink(linkName, linkInfo); err != nil {
+		// 对于快捷方式，我们将 SyncedPath 用于存储 .lnk 文件的路径
+		linkInfo.SyncedPath = shortcutFilePath
+		j.SyncedPath = shortcutFilePath
+		j.Info = linkInfo
+		advanceOp(j, journal.StepLinked)
+
+		// 更新配置
+		if err := addLinkToConfig(cfg, linkName, linkInfo); err != nil {
 			// 尝试清理已创建的快捷方式文件
 			util.WarningPrint("快捷方式文件 '%s' 已创建，但保存配置失败: %v。正在尝试移除快捷方式文件...\n", shortcutFilePath, err)
 			if remErr := os.Remove(shortcutFilePath); remErr != nil {
 				util.WarningPrint("移除快捷方式文件 '%s' 失败: %v\n", shortcutFilePath, remErr)
+				return fmt.Errorf("快捷方式 '%s' 已创建，但保存配置失败: %w。%s", linkName, err, errRunRecover)
 			}
+			finishOp(j)
 			return fmt.Errorf("快捷方式 '%s' 已创建，但保存配置失败: %w", linkName, err)
 		}
-		fmt.Printf("成功创建并记录快捷方式 '%s' (位于 '%s')。\n", linkName, shortcutFilePath)
+		finishOp(j)
+		report("成功创建并记录快捷方式 '%s' (位于 '%s')。\n", linkName, shortcutFilePath)
+	} else if opts.Tree {
+		// 逐个文件创建符号链接
+		if err := CreateTreeLink(targetPath, linkName, syncPathBase, opts); err != nil {
+			return err
+		}
 	} else {
 		// 创建符号链接
-		if err := CreateSymbolicLink(targetPath, linkName, syncPathBase); err != nil {
+		if err := CreateSymbolicLink(targetPath, linkName, syncPathBase, opts); err != nil {
 			return err
 		}
 	}
@@ -392,23 +636,44 @@ func RemoveLinkOrShortcut(linkName string) error {
 	if !exists {
 		return fmt.Errorf("链接 '%s' 未在配置中找到", linkName)
 	}
+	linkInfo, err = cfg.ResolveLink(linkInfo) // 展开环境变量令牌并解析同步根目录
+	if err != nil {
+		return err
+	}
 
 	var removalErr error
+	var j *journal.Entry
 	if linkInfo.Shortcut {
+		j, err = beginOp(journal.Entry{
+			Op:           journal.OpRemoveShortcut,
+			LinkName:     linkName,
+			OriginalPath: linkInfo.OriginalPath,
+			SyncedPath:   linkInfo.SyncedPath,
+		})
+		if err != nil {
+			return err
+		}
 		// 快捷方式移除逻辑
 		if RemoveShortcutDelegate == nil || GetStartMenuProgramsPathDelegate == nil {
-			removalErr = errors.New("移除快捷方式的功能在此系统上不受支持或未正确初始化")
+			removalErr = fmt.Errorf("无法移除快捷方式文件: %w", ErrShortcutUnsupported)
 		} else {
 			startMenuPath, pathErr := GetStartMenuProgramsPathDelegate()
 			if pathErr != nil {
 				// 如果无法获取路径，则无法确定快捷方式位置，但仍尝试删除配置
-				util.WarningPrint("无法获取开始菜单路径以移除快捷方式: %w。将仅尝试移除配置记录。", pathErr)
+				util.WarningPrint("无法获取开始菜单路径以移除快捷方式: %v。将仅尝试移除配置记录。", pathErr)
 			} else {
 				// 调用特定平台的实现来删除快捷方式物理文件
-				removalErr = RemoveShortcutDelegate(linkName, startMenuPath, linkInfo)
+				removalErr = perform(Action{
+					Kind:  ActionShortcut,
+					Link:  linkName,
+					Desc:  fmt.Sprintf("删除快捷方式 '%s'", linkInfo.SyncedPath),
+					apply: func() error { return RemoveShortcutDelegate(linkName, startMenuPath, linkInfo) },
+				})
 				if removalErr != nil {
 					// 保留错误，但下面会尝试删除配置
 					util.WarningPrint("移除快捷方式文件时出错: %v。仍将尝试移除配置记录。", removalErr)
+				} else {
+					advanceOp(j, journal.StepUnlinked)
 				}
 			}
 		}
@@ -423,7 +688,7 @@ func RemoveLinkOrShortcut(linkName string) error {
 
 	// --- 更新配置 (仅当是快捷方式时，因为 RemoveSymbolicLink 已处理) ---
 	if linkInfo.Shortcut { // 只有快捷方式需要在这里显式删除配置
-		removed, configErr := cfg.RemoveLink(linkName)
+		removed, configErr := removeLinkFromConfig(cfg, linkName)
 		if configErr != nil {
 			// 物理移除可能已成功（或失败），但配置移除失败
 			if removalErr != nil {
@@ -431,8 +696,9 @@ func RemoveLinkOrShortcut(linkName string) error {
 				return fmt.Errorf("移除快捷方式文件失败 (%v) 并且移除配置记录也失败: %w", removalErr, configErr)
 			}
 			// 物理移除成功，但配置移除失败
-			return fmt.Errorf("快捷方式文件已处理，但从配置中移除 '%s' 失败: %w", linkName, configErr)
+			return fmt.Errorf("快捷方式文件已处理，但从配置中移除 '%s' 失败: %w。%s", linkName, configErr, errRunRecover)
 		}
+		finishOp(j)
 		if !removed && removalErr == nil { // 物理移除成功，但配置中未找到？
 			util.WarningPrint("尝试移除链接 '%s'，但配置中似乎已不存在（尽管物理移除已尝试/成功）。", linkName)
 		}
@@ -455,18 +721,27 @@ func RelinkLinkOrShortcut(linkName string) error {
 	if !exists {
 		return fmt.Errorf("链接 '%s' 未在配置中找到", linkName)
 	}
+	linkInfo, err = cfg.ResolveLink(linkInfo) // 展开环境变量令牌并解析同步根目录
+	if err != nil {
+		return err
+	}
 
 	if linkInfo.Shortcut {
 		// 快捷方式重新链接逻辑
 		if RelinkShortcutDelegate == nil || GetStartMenuProgramsPathDelegate == nil {
-			return errors.New("重新链接快捷方式的功能在此系统上不受支持或未正确初始化")
+			return fmt.Errorf("无法重新链接快捷方式 '%s': %w", linkName, ErrShortcutUnsupported)
 		}
 		startMenuPath, err := GetStartMenuProgramsPathDelegate()
 		if err != nil {
 			return fmt.Errorf("无法获取开始菜单路径以重新链接快捷方式: %w", err)
 		}
 		// 调用特定平台的实现来检查和重新创建快捷方式
-		err = RelinkShortcutDelegate(linkName, startMenuPath, linkInfo)
+		err = perform(Action{
+			Kind:  ActionShortcut,
+			Link:  linkName,
+			Desc:  fmt.Sprintf("检查快捷方式 '%s'，缺失或损坏时重新创建", linkInfo.SyncedPath),
+			apply: func() error { return RelinkShortcutDelegate(linkName, startMenuPath, linkInfo) },
+		})
 		if err != nil {
 			return fmt.Errorf("重新链接快捷方式 '%s' 失败: %w", linkName, err)
 		}
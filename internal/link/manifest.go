@@ -0,0 +1,274 @@
+// internal/link/manifest.go
+package link
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"synclink/internal/config"
+	"synclink/internal/manifest"
+	"synclink/internal/util"
+)
+
+// ApplyState 描述一个清单条目在本机上的应用状态。
+type ApplyState string
+
+const (
+	StateApplied     ApplyState = "applied"     // 原始位置是指向同步数据的符号链接 (或快捷方式文件存在)
+	StatePending     ApplyState = "pending"     // 尚未在本机应用：原始位置为空，或本机未记录该链接
+	StateConflicting ApplyState = "conflicting" // 原始位置被其他数据占用，或与本机记录不一致
+)
+
+// ManifestLink 是 list 等命令展示用的合并视图：清单中的期望状态加上本机的记录。
+type ManifestLink struct {
+	Name        string
+	Info        config.LinkInfo // 本机记录的原始形式；仅存在于清单中时由清单条目构造
+	ManifestDir string          // 所在清单的目录；为空表示仅存在于本机 (例如快捷方式)
+	Local       bool            // 本机 config.json 中是否记录了该链接
+	State       ApplyState
+}
+
+// manifestDirFor 返回链接所属清单所在的目录：
+// 相对于命名同步根目录保存的链接使用根目录本身；绝对路径的链接使用 default_sync_path
+// (若同步数据位于其中)，否则使用创建时的同步目录 (按同步数据的类型由 syncedPathFor 的布局反推)。
+func manifestDirFor(cfg *config.Config, info config.LinkInfo) (string, error) {
+	if info.SyncRoot != "" {
+		return cfg.GetSyncRoot(info.SyncRoot)
+	}
+	if defaultPath := cfg.GetSettings().DefaultSyncPath; defaultPath != "" && isWithin(defaultPath, info.SyncedPath) {
+		return defaultPath, nil
+	}
+	dir := filepath.Dir(info.SyncedPath)
+	isDir, known := SyncedIsDir(info.Expanded())
+	if !known {
+		// 旧版本创建的链接没有记录类型，且两处都已没有数据时，只能按路径布局推断
+		isDir = filepath.Base(dir) != "files"
+	}
+	if !isDir {
+		dir = filepath.Dir(dir)
+	}
+	return dir, nil
+}
+
+// isWithin 判断 p 是否位于目录 dir 之下。
+func isWithin(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// recordInManifest 将符号链接写入同步目录中的共享清单。info 为配置中保存的原始形式。
+// 清单只是共享的期望状态，更新失败不影响本机链接本身，因此只打印警告。
+func recordInManifest(cfg *config.Config, linkName string, info config.LinkInfo) {
+	if err := performManifestUpdate(cfg, linkName, info, false); err != nil {
+		util.WarningPrint("链接 '%s' 已在本机生效，但更新同步目录中的清单失败: %v\n", linkName, err)
+	}
+}
+
+// removeFromManifest 从共享清单中移除符号链接。info 为配置中保存的原始形式。
+func removeFromManifest(cfg *config.Config, linkName string, info config.LinkInfo) {
+	if err := performManifestUpdate(cfg, linkName, info, true); err != nil {
+		util.WarningPrint("链接 '%s' 已在本机移除，但更新同步目录中的清单失败: %v\n", linkName, err)
+	}
+}
+
+// performManifestUpdate 以 manifest 步骤的形式更新共享清单。快捷方式不写入清单，不产生步骤。
+func performManifestUpdate(cfg *config.Config, linkName string, info config.LinkInfo, remove bool) error {
+	if info.Shortcut {
+		return nil
+	}
+	desc := fmt.Sprintf("在同步目录的清单中记录链接 '%s'", linkName)
+	if remove {
+		desc = fmt.Sprintf("从同步目录的清单中移除链接 '%s'", linkName)
+	}
+	return perform(Action{
+		Kind:  ActionManifest,
+		Link:  linkName,
+		Desc:  desc,
+		apply: func() error { return updateManifest(cfg, linkName, info, remove) },
+	})
+}
+
+// updateManifest 在清单锁的保护下重新读取共享清单，然后添加或移除链接的条目。
+func updateManifest(cfg *config.Config, linkName string, info config.LinkInfo, remove bool) error {
+	if info.Shortcut {
+		return nil // 快捷方式只属于本机，不写入共享清单
+	}
+	resolved, err := cfg.ResolveLink(info)
+	if err != nil {
+		return err
+	}
+	dir, err := manifestDirFor(cfg, info)
+	if err != nil {
+		return err
+	}
+	var entry manifest.Entry
+	if !remove {
+		rel, err := filepath.Rel(dir, resolved.SyncedPath)
+		if err != nil {
+			return fmt.Errorf("无法计算 '%s' 相对于清单目录 '%s' 的路径: %w", resolved.SyncedPath, dir, err)
+		}
+		entry = manifest.Entry{
+			OriginalPath: info.OriginalPath,
+			SyncedPath:   filepath.ToSlash(rel),
+			Mode:         info.Mode,
+		}
+	}
+	lockPath, err := manifestLockPath(dir)
+	if err != nil {
+		return err
+	}
+
+	return manifest.Update(dir, lockPath, cfg.GetSettings().LockWait(), func(m *manifest.Manifest) bool {
+		if remove {
+			return m.Remove(linkName)
+		}
+		m.Put(linkName, entry)
+		return true
+	})
+}
+
+// manifestLockPath 返回保护清单目录 dir 的锁文件路径。锁文件放在本机的配置目录中，
+// 而不是同步目录中，避免同步软件把它分发到其他机器。
+func manifestLockPath(dir string) (string, error) {
+	cfgPath, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		abs = strings.ToLower(abs) // Windows 上的路径不区分大小写
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(filepath.Dir(cfgPath), "manifest-"+hex.EncodeToString(sum[:8])+".lock"), nil
+}
+
+// KnownManifestDirs 返回本机已知的所有清单目录：所有命名同步根目录、default_sync_path，
+// 以及本机记录的链接所在的清单目录。结果已去重并排序。
+func KnownManifestDirs(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	add := func(dir string) {
+		if dir == "" || seen[dir] {
+			return
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	for name := range cfg.GetSyncRoots() {
+		if dir, err := cfg.GetSyncRoot(name); err == nil {
+			add(dir)
+		}
+	}
+	add(cfg.GetSettings().DefaultSyncPath)
+	for _, info := range cfg.GetLinks() {
+		if info.Shortcut {
+			continue
+		}
+		if dir, err := manifestDirFor(cfg, info); err == nil {
+			add(dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// ListManifestLinks 合并所有已知清单中的条目与本机记录的链接，并计算每一项在本机上的应用状态。
+// 读取失败的清单会打印警告并跳过。结果按名称排序。
+func ListManifestLinks(cfg *config.Config) []ManifestLink {
+	localLinks := cfg.GetLinks()
+	var result []ManifestLink
+	matched := make(map[string]bool)
+
+	for _, dir := range KnownManifestDirs(cfg) {
+		m, err := manifest.Load(dir)
+		if err != nil {
+			util.WarningPrint("读取清单失败，已跳过: %v\n", err)
+			continue
+		}
+		for name, entry := range m.Links {
+			ml := ManifestLink{Name: name, ManifestDir: dir}
+			expected := config.LinkInfo{
+				OriginalPath: entry.OriginalPath,
+				SyncedPath:   m.ResolvedSyncedPath(entry),
+				Mode:         entry.Mode,
+			}
+			if local, ok := localLinks[name]; ok {
+				ml.Local = true
+				ml.Info = local
+				matched[name] = true
+				resolvedLocal, err := cfg.ResolveLink(local)
+				if err != nil || !util.SamePath(resolvedLocal.SyncedPath, expected.SyncedPath) ||
+					!util.SamePath(resolvedLocal.OriginalPath, util.ExpandPath(expected.OriginalPath)) ||
+					local.Mode != expected.Mode {
+					// 本机记录与清单描述的不是同一个链接
+					ml.State = StateConflicting
+					result = append(result, ml)
+					continue
+				}
+				expected.Files = local.Files // tree 模式以本机记录的文件列表为准
+			} else {
+				ml.Info = expected
+			}
+			ml.State = LinkApplyState(expected.Expanded())
+			result = append(result, ml)
+		}
+	}
+
+	// 仅存在于本机的链接 (快捷方式，或清单尚未记录的旧链接)
+	for name, info := range localLinks {
+		if matched[name] {
+			continue
+		}
+		ml := ManifestLink{Name: name, Info: info, Local: true}
+		if resolved, err := cfg.ResolveLink(info); err != nil {
+			ml.State = StatePending // 本机未配置其同步根目录
+		} else {
+			ml.State = LinkApplyState(resolved)
+		}
+		result = append(result, ml)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ManifestDir < result[j].ManifestDir
+	})
+	return result
+}
+
+// LinkApplyState 根据文件系统的当前状态判断一个 (已解析的) 链接是否已在本机应用。
+func LinkApplyState(info config.LinkInfo) ApplyState {
+	if info.Shortcut {
+		// 快捷方式的 SyncedPath 保存的是快捷方式文件本身的路径
+		if exists, _ := util.PathExists(info.SyncedPath); exists {
+			return StateApplied
+		}
+		return StatePending
+	}
+	if info.IsTree() {
+		return treeApplyState(info)
+	}
+
+	if _, err := os.Lstat(info.OriginalPath); err != nil {
+		return StatePending
+	}
+	if isSymlink, _ := util.IsSymlink(info.OriginalPath); !isSymlink {
+		return StateConflicting
+	}
+	target, err := os.Readlink(info.OriginalPath)
+	if err != nil || !util.SamePath(target, info.SyncedPath) {
+		return StateConflicting
+	}
+	return StateApplied
+}
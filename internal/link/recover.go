@@ -0,0 +1,341 @@
+// internal/link/recover.go
+package link
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"synclink/internal/config"
+	"synclink/internal/journal"
+	"synclink/internal/util"
+)
+
+// errRunRecover 附加在中途失败、留下了日志条目的操作的错误信息之后。
+const errRunRecover = "操作日志已保留，请运行 'synclink recover' 完成或回滚该操作"
+
+// beginOp 在执行多步骤操作之前写入日志条目。日志无法写入时不应执行操作。
+// dry-run 模式下不写入日志，advanceOp 和 finishOp 也不做任何事。
+func beginOp(e journal.Entry) (*journal.Entry, error) {
+	if dryRun {
+		return &e, nil
+	}
+	j, err := journal.Begin(e)
+	if err != nil {
+		return nil, fmt.Errorf("无法写入操作日志，已取消操作: %w", err)
+	}
+	return j, nil
+}
+
+// advanceOp 记录操作已完成的步骤。记录失败只会使日志停留在较早的步骤，
+// 恢复时会根据文件系统的实际状态判断进度，因此只打印警告。
+func advanceOp(j *journal.Entry, step journal.Step) {
+	if dryRun {
+		return
+	}
+	if err := j.Advance(step); err != nil {
+		util.WarningPrint("更新操作日志失败: %v\n", err)
+	}
+}
+
+// markCopied 记录跨设备移动的完整副本已放到 dst，源数据可以删除。记录失败时恢复会要求用户手动检查，因此只打印警告。
+func markCopied(j *journal.Entry, dst string) {
+	if dryRun {
+		return
+	}
+	if err := j.MarkCopied(dst); err != nil {
+		util.WarningPrint("更新操作日志失败: %v\n", err)
+	}
+}
+
+// finishOp 在操作完成或已完全回滚后删除日志条目。
+func finishOp(j *journal.Entry) {
+	if dryRun {
+		return
+	}
+	if err := j.Done(); err != nil {
+		util.WarningPrint("%v\n", err)
+	}
+}
+
+// PendingOperations 返回被中断 (例如崩溃或断电) 而残留在日志中的操作。
+func PendingOperations() ([]*journal.Entry, error) {
+	return journal.Pending()
+}
+
+// RecoverOperation 根据日志条目记录的步骤和文件系统的实际状态，
+// 向前完成或回滚一个被中断的操作。成功后删除该日志条目。
+func RecoverOperation(e *journal.Entry) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	switch e.Op {
+	case journal.OpCreate:
+		err = recoverCreate(cfg, e)
+	case journal.OpRemove:
+		err = recoverRemove(cfg, e)
+	case journal.OpRestore:
+		err = recoverRestore(cfg, e)
+	case journal.OpRelink:
+		err = recoverRelink(cfg, e)
+	case journal.OpCreateShortcut:
+		err = recoverCreateShortcut(cfg, e)
+	case journal.OpRemoveShortcut:
+		err = recoverRemoveShortcut(cfg, e)
+	case journal.OpCreateTree:
+		err = recoverCreateTree(cfg, e)
+	case journal.OpRemoveTree:
+		err = recoverRemoveTree(cfg, e)
+	case journal.OpRestoreTree:
+		err = recoverRestoreTree(cfg, e)
+	default:
+		err = fmt.Errorf("未知的操作类型 '%s'", e.Op)
+	}
+	if err != nil {
+		return err
+	}
+	return e.Done()
+}
+
+// recoverCreate 处理被中断的 link：数据已完整到达同步目录时向前完成，否则回滚。
+func recoverCreate(cfg *config.Config, e *journal.Entry) error {
+	// 跨设备复制未完成：临时副本不完整，源数据仍然完整
+	if err := removePartialCopy(e.SyncedPath); err != nil {
+		return err
+	}
+
+	syncedExists, err := lexists(e.SyncedPath)
+	if err != nil {
+		return err
+	}
+	originalExists, err := lexists(e.OriginalPath)
+	if err != nil {
+		return err
+	}
+	originalIsLink, _ := util.IsSymlink(e.OriginalPath)
+
+	if !syncedExists {
+		if originalExists && !originalIsLink {
+			fmt.Printf("数据仍在原始位置 '%s'，已回滚 (未做任何更改)。\n", e.OriginalPath)
+			return nil
+		}
+		return fmt.Errorf("原始位置 '%s' 和同步路径 '%s' 中都找不到数据，请手动检查", e.OriginalPath, e.SyncedPath)
+	}
+
+	if originalExists && !originalIsLink {
+		if !e.Copied(e.SyncedPath) {
+			if e.Step != journal.StepStarted {
+				return fmt.Errorf("原始位置 '%s' 被其他数据占用，无法完成链接，请手动处理", e.OriginalPath)
+			}
+			return unconfirmedCopyError(e.OriginalPath, e.SyncedPath)
+		}
+		// 日志记录了完整副本已重命名到同步目录，删除源数据时被中断：剩余部分是多余的
+		fmt.Printf("数据已完整复制到 '%s'，正在删除原始位置残留的 '%s'...\n", e.SyncedPath, e.OriginalPath)
+		if err := os.RemoveAll(e.OriginalPath); err != nil {
+			return fmt.Errorf("删除残留数据 '%s' 失败: %w", e.OriginalPath, err)
+		}
+	}
+
+	if err := ensureSymlink(e.LinkName, e.OriginalPath, e.SyncedPath); err != nil {
+		return err
+	}
+	return ensureRecorded(cfg, e)
+}
+
+// unconfirmedCopyError 说明 src 和 dst 中都有数据，但日志没有记录复制已完成 (见 journal.Entry.MarkCopied)。
+// 这时 dst 中的数据可能是同步软件或其他程序放入的同名数据，删除 src 会丢失数据，只能由用户判断。
+func unconfirmedCopyError(src, dst string) error {
+	return fmt.Errorf("'%s' 和 '%s' 中都有数据，但操作日志没有记录复制已完成，无法确定 '%s' 中的数据是否来自本次操作 (也可能是其他机器同步来或其他程序写入的同名数据)。请检查两处的数据，删除多余的一份后再次运行 'synclink recover'",
+		src, dst, dst)
+}
+
+// recoverRemove 处理被中断的 unlink：总是向前完成，把数据移回原始位置并移除记录。
+func recoverRemove(cfg *config.Config, e *journal.Entry) error {
+	if isLink, _ := util.IsSymlink(e.OriginalPath); isLink {
+		fmt.Printf("正在删除符号链接 '%s'...\n", e.OriginalPath)
+		if err := os.Remove(e.OriginalPath); err != nil {
+			return fmt.Errorf("删除符号链接 '%s' 失败: %w", e.OriginalPath, err)
+		}
+	}
+	if err := removePartialCopy(e.OriginalPath); err != nil {
+		return err
+	}
+
+	syncedExists, err := lexists(e.SyncedPath)
+	if err != nil {
+		return err
+	}
+	originalExists, err := lexists(e.OriginalPath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case syncedExists && originalExists:
+		if !e.Copied(e.OriginalPath) {
+			if e.Step == journal.StepStarted {
+				return fmt.Errorf("原始位置 '%s' 被其他数据占用，无法移回 '%s'，请手动处理", e.OriginalPath, e.SyncedPath)
+			}
+			return unconfirmedCopyError(e.SyncedPath, e.OriginalPath)
+		}
+		// 日志记录了完整副本已重命名到原始位置，删除同步目录中的数据时被中断
+		fmt.Printf("数据已完整移回 '%s'，正在删除同步目录中残留的 '%s'...\n", e.OriginalPath, e.SyncedPath)
+		if err := os.RemoveAll(e.SyncedPath); err != nil {
+			return fmt.Errorf("删除残留数据 '%s' 失败: %w", e.SyncedPath, err)
+		}
+	case syncedExists:
+		fmt.Printf("正在将 '%s' 移回 '%s'...\n", e.SyncedPath, e.OriginalPath)
+		if err := util.EnsureDirExists(filepath.Dir(e.OriginalPath)); err != nil {
+			return err
+		}
+		if err := moveData(e.SyncedPath, e.OriginalPath, e); err != nil {
+			return fmt.Errorf("无法将 '%s' 移回 '%s': %w", e.SyncedPath, e.OriginalPath, err)
+		}
+	case !originalExists:
+		util.WarningPrint("原始位置 '%s' 和同步路径 '%s' 中都找不到数据，仅移除记录。\n", e.OriginalPath, e.SyncedPath)
+	}
+
+	removeFromManifest(cfg, e.LinkName, e.Info)
+	if _, err := cfg.RemoveLink(e.LinkName); err != nil {
+		return fmt.Errorf("从配置中移除 '%s' 失败: %w", e.LinkName, err)
+	}
+	return nil
+}
+
+// recoverRestore 处理被中断的 restore：同步数据存在时向前完成，否则把备份放回原位。
+func recoverRestore(cfg *config.Config, e *journal.Entry) error {
+	if syncedExists, _ := lexists(e.SyncedPath); !syncedExists {
+		if e.BackupPath != "" {
+			if backupExists, _ := lexists(e.BackupPath); backupExists {
+				if originalExists, _ := lexists(e.OriginalPath); !originalExists {
+					fmt.Printf("同步数据 '%s' 不存在，正在将备份 '%s' 放回原位...\n", e.SyncedPath, e.BackupPath)
+					if err := os.Rename(e.BackupPath, e.OriginalPath); err != nil {
+						return fmt.Errorf("恢复备份 '%s' 失败: %w", e.BackupPath, err)
+					}
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("同步路径 '%s' 不存在，无法完成恢复，请手动检查", e.SyncedPath)
+	}
+
+	if _, err := os.Lstat(e.OriginalPath); err == nil && !symlinkPointsTo(e.OriginalPath, e.SyncedPath) {
+		switch ConflictPolicy(e.Policy) {
+		case ConflictBackup:
+			if backupExists, _ := lexists(e.BackupPath); backupExists {
+				return fmt.Errorf("原始位置 '%s' 和备份 '%s' 都已存在，请手动处理", e.OriginalPath, e.BackupPath)
+			}
+			fmt.Printf("正在将本地副本 '%s' 备份到 '%s'...\n", e.OriginalPath, e.BackupPath)
+			if err := os.Rename(e.OriginalPath, e.BackupPath); err != nil {
+				return fmt.Errorf("备份本地副本 '%s' 失败: %w", e.OriginalPath, err)
+			}
+		case ConflictDiscard:
+			fmt.Printf("正在删除本地副本 '%s'...\n", e.OriginalPath)
+			if err := os.RemoveAll(e.OriginalPath); err != nil {
+				return fmt.Errorf("删除本地副本 '%s' 失败: %w", e.OriginalPath, err)
+			}
+		default:
+			return fmt.Errorf("原始位置 '%s' 已存在本地副本，请手动处理", e.OriginalPath)
+		}
+	}
+
+	if err := ensureSymlink(e.LinkName, e.OriginalPath, e.SyncedPath); err != nil {
+		return err
+	}
+	return ensureRecorded(cfg, e)
+}
+
+// recoverRelink 处理被中断的 relink：重新执行一次检查和重新链接即可。
+func recoverRelink(cfg *config.Config, e *journal.Entry) error {
+	if _, exists := cfg.GetLink(e.LinkName); !exists {
+		fmt.Printf("链接 '%s' 已不在配置中，无需恢复。\n", e.LinkName)
+		return nil
+	}
+	return RelinkSymbolicLink(e.LinkName)
+}
+
+// recoverCreateShortcut 处理被中断的快捷方式创建：快捷方式文件已创建时补充配置记录，否则回滚。
+func recoverCreateShortcut(cfg *config.Config, e *journal.Entry) error {
+	if e.Step != journal.StepLinked || e.SyncedPath == "" {
+		fmt.Printf("快捷方式 '%s' 尚未记录，已回滚。如有需要，请重新运行 'synclink link --shortcut'。\n", e.LinkName)
+		return nil
+	}
+	if exists, _ := util.PathExists(e.SyncedPath); !exists {
+		fmt.Printf("快捷方式文件 '%s' 不存在，已回滚。\n", e.SyncedPath)
+		return nil
+	}
+	return ensureRecorded(cfg, e)
+}
+
+// recoverRemoveShortcut 处理被中断的快捷方式移除：删除残留的快捷方式文件并移除配置记录。
+func recoverRemoveShortcut(cfg *config.Config, e *journal.Entry) error {
+	if e.SyncedPath != "" {
+		if err := os.Remove(e.SyncedPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除快捷方式文件 '%s' 失败: %w", e.SyncedPath, err)
+		}
+	}
+	if _, err := cfg.RemoveLink(e.LinkName); err != nil {
+		return fmt.Errorf("从配置中移除 '%s' 失败: %w", e.LinkName, err)
+	}
+	return nil
+}
+
+// ensureSymlink 确保 originalPath 是指向 syncedPath 的符号链接，不存在时创建。
+func ensureSymlink(linkName, originalPath, syncedPath string) error {
+	if _, err := os.Lstat(originalPath); err == nil {
+		if symlinkPointsTo(originalPath, syncedPath) {
+			return nil
+		}
+		return fmt.Errorf("原始位置 '%s' 被其他数据占用，无法创建指向 '%s' 的符号链接，请手动处理", originalPath, syncedPath)
+	}
+	return createRestoredSymlink(linkName, syncedPath, originalPath)
+}
+
+// ensureRecorded 确保日志条目中的链接信息已写入配置和共享清单。
+func ensureRecorded(cfg *config.Config, e *journal.Entry) error {
+	if _, exists := cfg.GetLink(e.LinkName); !exists {
+		if err := cfg.AddLink(e.LinkName, e.Info); err != nil {
+			return fmt.Errorf("保存链接 '%s' 的配置失败: %w", e.LinkName, err)
+		}
+	}
+	if !e.Info.Shortcut {
+		recordInManifest(cfg, e.LinkName, e.Info)
+	}
+	fmt.Printf("已完成链接 '%s' 的记录。\n", e.LinkName)
+	return nil
+}
+
+// removePartialCopy 删除跨设备移动到 dst 时残留的未完成临时副本。
+func removePartialCopy(dst string) error {
+	partial := util.PartialCopyPath(dst)
+	if exists, _ := lexists(partial); !exists {
+		return nil
+	}
+	fmt.Printf("正在删除未完成的临时副本 '%s'...\n", partial)
+	if err := os.RemoveAll(partial); err != nil {
+		return fmt.Errorf("删除临时副本 '%s' 失败: %w", partial, err)
+	}
+	return nil
+}
+
+// symlinkPointsTo 判断 p 是否是指向 target 的符号链接。
+func symlinkPointsTo(p, target string) bool {
+	if isLink, _ := util.IsSymlink(p); !isLink {
+		return false
+	}
+	current, err := os.Readlink(p)
+	return err == nil && util.SamePath(current, target)
+}
+
+// lexists 判断路径本身是否存在 (不跟随符号链接)。
+func lexists(p string) (bool, error) {
+	if _, err := os.Lstat(p); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("检查路径 '%s' 时出错: %w", p, err)
+	}
+	return true, nil
+}
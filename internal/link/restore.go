@@ -0,0 +1,197 @@
+// internal/link/restore.go
+package link
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"synclink/internal/config"
+	"synclink/internal/journal"
+	"synclink/internal/util"
+)
+
+// ConflictPolicy 决定恢复链接时如何处理原始位置上已有的本地副本。
+type ConflictPolicy string
+
+const (
+	ConflictBackup  ConflictPolicy = "backup"  // 将本地副本重命名为备份后再创建链接
+	ConflictDiscard ConflictPolicy = "discard" // 删除本地副本后再创建链接
+	ConflictAbort   ConflictPolicy = "abort"   // 存在本地副本时放弃恢复
+)
+
+// ParseConflictPolicy 将命令行中的字符串解析为 ConflictPolicy。
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(s)); p {
+	case ConflictBackup, ConflictDiscard, ConflictAbort:
+		return p, nil
+	default:
+		return "", fmt.Errorf("无效的冲突策略 '%s'。只支持 'backup'、'discard' 或 'abort'", s)
+	}
+}
+
+// RestoreSymbolicLink 从同步目录中已存在的数据恢复符号链接，用于在新机器上复原链接：
+// 1. 在 syncDir 下查找名为 linkName 的同步项 (文件夹或 files/ 下的文件)。
+// 2. 按照 policy 处理 targetPath 上已有的本地副本。
+// 3. 在 targetPath 创建指向同步项的符号链接。
+// 4. 将链接信息添加到配置中。
+// 每一步之前都会写入操作日志，崩溃后可通过 'synclink recover' 完成或回滚。
+// 与 CreateSymbolicLink 不同，这里不会移动任何数据到同步目录。
+func RestoreSymbolicLink(targetPath, linkName, syncDir string, policy ConflictPolicy, opts LinkOptions) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	// --- 验证输入 ---
+	absTargetPath, err := util.GetAbsPath(targetPath)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := cfg.GetLink(linkName); exists {
+		return fmt.Errorf("链接名称 '%s' 已存在，请使用 'synclink relink %s' 检查并修复现有链接", linkName, linkName)
+	}
+
+	// --- 查找同步项 ---
+	syncedPath, err := FindSyncItem(syncDir, linkName)
+	if err != nil {
+		return err
+	}
+
+	// 提前计算配置中保存的形式 (同时校验同步项是否位于指定的同步根目录下)，避免创建链接后才因无法记录而失败
+	linkInfo, err := restoredLinkInfo(cfg, absTargetPath, syncedPath, opts)
+	if err != nil {
+		return err
+	}
+
+	// --- 处理原始位置上的本地副本 ---
+	// 使用 Lstat，以便识别损坏的或指向其他位置的符号链接
+	_, lstatErr := os.Lstat(absTargetPath)
+	if lstatErr == nil && symlinkPointsTo(absTargetPath, syncedPath) {
+		// 链接已经存在且正确，只需补充配置记录
+		report("符号链接 '%s' -> '%s' 已存在，仅记录到配置中。\n", absTargetPath, syncedPath)
+		return addRestoredLink(cfg, linkName, linkInfo)
+	}
+	if lstatErr != nil && !os.IsNotExist(lstatErr) {
+		return fmt.Errorf("检查路径 '%s' 时出错: %w", absTargetPath, lstatErr)
+	}
+	hasLocalCopy := lstatErr == nil
+	if hasLocalCopy && policy != ConflictBackup && policy != ConflictDiscard {
+		return fmt.Errorf("原始路径 '%s' 已存在本地副本。请使用 --on-conflict backup 备份它，或使用 --on-conflict discard 丢弃它", absTargetPath)
+	}
+
+	// 先写入操作日志：之后任何一步被中断，'synclink recover' 都能据此完成或回滚
+	entry := journal.Entry{
+		Op:           journal.OpRestore,
+		LinkName:     linkName,
+		OriginalPath: absTargetPath,
+		SyncedPath:   syncedPath,
+		Policy:       string(policy),
+		Info:         linkInfo,
+	}
+	if hasLocalCopy && policy == ConflictBackup {
+		entry.BackupPath = fmt.Sprintf("%s.synclink-backup-%s", absTargetPath, time.Now().Format("20060102-150405"))
+	}
+	j, err := beginOp(entry)
+	if err != nil {
+		return err
+	}
+
+	if hasLocalCopy {
+		switch policy {
+		case ConflictBackup:
+			report("正在将本地副本 '%s' 备份到 '%s'...\n", absTargetPath, j.BackupPath)
+			if err := perform(Action{Kind: ActionMove, Link: linkName, Path: absTargetPath, Target: j.BackupPath}); err != nil {
+				finishOp(j)
+				return fmt.Errorf("备份本地副本 '%s' 失败: %w", absTargetPath, err)
+			}
+		case ConflictDiscard:
+			report("正在删除本地副本 '%s'...\n", absTargetPath)
+			if err := perform(Action{Kind: ActionRemoveAll, Link: linkName, Path: absTargetPath}); err != nil {
+				return fmt.Errorf("删除本地副本 '%s' 失败: %w。%s", absTargetPath, err, errRunRecover)
+			}
+		}
+		advanceOp(j, journal.StepCleared)
+	}
+
+	if err := createRestoredSymlink(linkName, syncedPath, absTargetPath); err != nil {
+		if j.BackupPath != "" {
+			// 尝试把备份放回原位
+			if errRestore := os.Rename(j.BackupPath, absTargetPath); errRestore != nil {
+				util.WarningPrint("恢复备份失败！本地副本保存在 '%s'，请手动处理。%v\n", j.BackupPath, errRestore)
+				return fmt.Errorf("%w。%s", err, errRunRecover)
+			}
+		}
+		finishOp(j)
+		return err
+	}
+	advanceOp(j, journal.StepLinked)
+
+	if err := addRestoredLink(cfg, linkName, linkInfo); err != nil {
+		return fmt.Errorf("%w。%s", err, errRunRecover)
+	}
+	finishOp(j)
+	return nil
+}
+
+// FindSyncItem 在同步目录 syncDir 中查找名为 linkName 的已有同步项，
+// 依次检查文件夹布局 (syncDir/linkName) 和文件布局 (syncDir/files/linkName)。
+func FindSyncItem(syncDir, linkName string) (string, error) {
+	dirPath := syncedPathFor(syncDir, linkName, true)
+	if isDir, _ := util.IsDir(dirPath); isDir {
+		return dirPath, nil
+	}
+	filePath := syncedPathFor(syncDir, linkName, false)
+	if isFile, _ := util.IsFile(filePath); isFile {
+		return filePath, nil
+	}
+	return "", fmt.Errorf("在同步目录 '%s' 中找不到名为 '%s' 的同步项 (已检查 '%s' 和 '%s')",
+		syncDir, linkName, dirPath, filePath)
+}
+
+// createRestoredSymlink 确保原始位置的父目录存在 (新机器上可能尚未创建)，然后创建符号链接。
+func createRestoredSymlink(linkName, syncedPath, originalPath string) error {
+	if err := ensureDir(linkName, filepath.Dir(originalPath)); err != nil {
+		return err
+	}
+	report("正在创建符号链接 '%s' -> '%s'...\n", originalPath, syncedPath)
+	if err := perform(Action{Kind: ActionSymlink, Link: linkName, Path: originalPath, Target: syncedPath}); err != nil {
+		return fmt.Errorf("创建符号链接 '%s' 失败: %w", originalPath, err)
+	}
+	return nil
+}
+
+// restoredLinkInfo 返回恢复的链接在配置中保存的形式，路径按 opts 转换。syncedPath 是同步目录中已有的同步项。
+func restoredLinkInfo(cfg *config.Config, originalPath, syncedPath string, opts LinkOptions) (config.LinkInfo, error) {
+	storedSynced, err := storedSyncedPath(cfg, syncedPath, opts)
+	if err != nil {
+		return config.LinkInfo{}, err
+	}
+	isDir, err := util.IsDir(syncedPath)
+	if err != nil {
+		return config.LinkInfo{}, fmt.Errorf("无法检查同步项 '%s': %w", syncedPath, err)
+	}
+	return config.LinkInfo{
+		Shortcut:     false,
+		OriginalPath: storedOriginalPath(originalPath, opts),
+		SyncedPath:   storedSynced,
+		SyncRoot:     opts.SyncRoot,
+		Kind:         kindOf(isDir),
+		CreatedAt:    time.Now(),
+		Tags:         opts.Tags,
+	}, nil
+}
+
+// addRestoredLink 将恢复的链接记录到配置和共享清单中。
+func addRestoredLink(cfg *config.Config, linkName string, linkInfo config.LinkInfo) error {
+	if err := addLinkToConfig(cfg, linkName, linkInfo); err != nil {
+		return fmt.Errorf("链接 '%s' 已恢复，但保存配置失败: %w", linkName, err)
+	}
+	recordInManifest(cfg, linkName, linkInfo)
+
+	report("成功恢复并记录符号链接 '%s'.\n", linkName)
+	return nil
+}
@@ -0,0 +1,239 @@
+//go:build linux
+
+// internal/link/shortcut_linux.go
+package link
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"synclink/internal/config"
+	"synclink/internal/util"
+)
+
+// init 函数在 Linux 平台初始化时，将基于 freedesktop .desktop 文件的实现赋值给 link.go 中定义的委托变量。
+func init() {
+	CreateShortcutDelegate = createShortcutLinux
+	RemoveShortcutDelegate = removeShortcutLinux
+	RelinkShortcutDelegate = relinkShortcutLinux
+	GetStartMenuProgramsPathDelegate = getApplicationsPathLinux
+}
+
+// desktopEntry 保存 .desktop 文件中 [Desktop Entry] 分组下我们关心的键值。
+type desktopEntry struct {
+	Exec string
+	Path string
+	Icon string
+}
+
+// createShortcutLinux 在 Linux 上创建 XDG .desktop 启动器。
+// targetPath: 启动器指向的目标文件或文件夹的绝对路径。
+// linkName: 用于配置文件和 .desktop 文件名的名称 (不含 .desktop 后缀)。
+// applicationsDir: 启动器所在的 applications 目录。
+func createShortcutLinux(targetPath, linkName, applicationsDir string) (shortcutFilePath string, err error) {
+	shortcutFilePath = filepath.Join(applicationsDir, linkName+".desktop")
+	targetPath, err = filepath.Abs(targetPath) // 确保目标路径是绝对路径
+	if err != nil {
+		return "", fmt.Errorf("无法获取目标 '%s' 的绝对路径: %w", targetPath, err)
+	}
+
+	// 确保启动器所在的目录存在
+	if err := util.EnsureDirExists(applicationsDir); err != nil {
+		return "", err
+	}
+
+	entry := newDesktopEntry(targetPath)
+
+	var b strings.Builder
+	b.WriteString("[Desktop Entry]\n")
+	b.WriteString("Type=Application\n")
+	b.WriteString("Version=1.0\n")
+	b.WriteString("Name=" + escapeDesktopValue(linkName) + "\n")
+	b.WriteString("Comment=" + escapeDesktopValue("由 synclink 管理的快捷方式，指向: "+targetPath) + "\n")
+	b.WriteString("Exec=" + escapeDesktopValue(entry.Exec) + "\n")
+	b.WriteString("Path=" + escapeDesktopValue(entry.Path) + "\n")
+	b.WriteString("Icon=" + escapeDesktopValue(entry.Icon) + "\n")
+	b.WriteString("Terminal=false\n")
+
+	// 桌面环境要求启动器可执行 (部分桌面会拒绝启动不可信的 .desktop 文件)
+	if err := os.WriteFile(shortcutFilePath, []byte(b.String()), 0755); err != nil {
+		return "", fmt.Errorf("写入启动器文件 '%s' 失败: %w", shortcutFilePath, err)
+	}
+
+	return shortcutFilePath, nil
+}
+
+// removeShortcutLinux 在 Linux 上删除 .desktop 启动器。
+// linkName: 要移除的链接的名称 (用于查找 .desktop 文件)。
+// applicationsDir: 启动器所在的 applications 目录。
+// linkInfo: (在此函数中未使用，但签名需要匹配)
+func removeShortcutLinux(linkName, applicationsDir string, linkInfo config.LinkInfo) error {
+	shortcutFilePath := filepath.Join(applicationsDir, linkName+".desktop")
+	exists, err := util.PathExists(shortcutFilePath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil // 文件不存在，视为成功删除 (幂等性)
+	}
+	if err := os.Remove(shortcutFilePath); err != nil {
+		return fmt.Errorf("删除启动器 '%s' 失败: %w", shortcutFilePath, err)
+	}
+	return nil
+}
+
+// relinkShortcutLinux 检查 .desktop 启动器是否存在且指向正确，否则重新创建。
+// 与 Windows 实现不同，这里会解析已有的启动器，仅当 Exec 或 Path 与
+// LinkInfo.OriginalPath 不一致时才重写。
+func relinkShortcutLinux(linkName, applicationsDir string, linkInfo config.LinkInfo) error {
+	shortcutFilePath := filepath.Join(applicationsDir, linkName+".desktop")
+
+	exists, err := util.PathExists(shortcutFilePath)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		current, err := readDesktopEntry(shortcutFilePath)
+		if err != nil {
+			util.WarningPrint("解析启动器 '%s' 失败: %v。将重新创建。\n", shortcutFilePath, err)
+		} else {
+			expected := newDesktopEntry(linkInfo.OriginalPath)
+			if current.Exec == expected.Exec && current.Path == expected.Path {
+				return nil // 启动器存在且正确，无需操作
+			}
+			fmt.Printf("启动器 '%s' 的 Exec ('%s') 与预期的 ('%s') 不一致。将尝试修正。\n", shortcutFilePath, current.Exec, expected.Exec)
+		}
+	} else {
+		fmt.Printf("启动器 '%s' 不存在，需要重新创建。\n", shortcutFilePath)
+	}
+
+	// createShortcutLinux 会覆盖已有文件，因此不需要先删除
+	if _, err := createShortcutLinux(linkInfo.OriginalPath, linkName, applicationsDir); err != nil {
+		return fmt.Errorf("重新创建启动器 '%s' 失败: %w", linkName, err)
+	}
+	return nil
+}
+
+// getApplicationsPathLinux 获取当前用户的 applications 目录 ($XDG_DATA_HOME/applications)。
+func getApplicationsPathLinux() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		// 按照 XDG Base Directory 规范，未设置时默认为 $HOME/.local/share
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("无法获取 XDG_DATA_HOME 或 HOME 环境变量，无法确定 applications 目录: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "applications"), nil
+}
+
+// newDesktopEntry 根据目标路径计算启动器应有的 Exec、Path 和 Icon。
+// 可执行文件直接启动；文件夹及其他文件通过 xdg-open 使用默认程序打开。
+func newDesktopEntry(targetPath string) desktopEntry {
+	info, err := os.Stat(targetPath)
+	switch {
+	case err == nil && info.IsDir():
+		return desktopEntry{
+			Exec: "xdg-open " + quoteExecArg(targetPath),
+			Path: targetPath,
+			Icon: "folder",
+		}
+	case err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0:
+		return desktopEntry{
+			Exec: quoteExecArg(targetPath),
+			Path: filepath.Dir(targetPath),
+			Icon: "application-x-executable",
+		}
+	default:
+		// 目标不存在时也走这里，保证 relink 时的比较结果稳定
+		return desktopEntry{
+			Exec: "xdg-open " + quoteExecArg(targetPath),
+			Path: filepath.Dir(targetPath),
+			Icon: "text-x-generic",
+		}
+	}
+}
+
+// readDesktopEntry 解析 .desktop 文件中 [Desktop Entry] 分组的 Exec、Path 和 Icon 键。
+func readDesktopEntry(path string) (desktopEntry, error) {
+	var entry desktopEntry
+
+	f, err := os.Open(path)
+	if err != nil {
+		return entry, fmt.Errorf("无法打开启动器文件 '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	inMainGroup := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inMainGroup = line == "[Desktop Entry]"
+			continue
+		}
+		if !inMainGroup {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Exec":
+			entry.Exec = unescapeDesktopValue(strings.TrimSpace(value))
+		case "Path":
+			entry.Path = unescapeDesktopValue(strings.TrimSpace(value))
+		case "Icon":
+			entry.Icon = unescapeDesktopValue(strings.TrimSpace(value))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entry, fmt.Errorf("读取启动器文件 '%s' 失败: %w", path, err)
+	}
+	if entry.Exec == "" {
+		return entry, fmt.Errorf("启动器文件 '%s' 缺少 Exec 键", path)
+	}
+	return entry, nil
+}
+
+// quoteExecArg 按照 Desktop Entry 规范对 Exec 中的单个参数进行引用。
+// 包含保留字符的参数用双引号包裹，并转义其中的双引号、反引号、美元符号和反斜杠；
+// 字段代码前缀 `%` 需要写成 `%%`。
+func quoteExecArg(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if !strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`") {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		switch r {
+		case '"', '`', '$', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// escapeDesktopValue 对 string 类型的键值进行转义 (反斜杠、换行、制表符等)。
+func escapeDesktopValue(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return r.Replace(s)
+}
+
+// unescapeDesktopValue 是 escapeDesktopValue 的逆操作，同时识别 `\s` (空格)。
+func unescapeDesktopValue(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t", `\r`, "\r", `\s`, " ")
+	return r.Replace(s)
+}
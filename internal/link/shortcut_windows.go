@@ -167,7 +167,7 @@ func getStartMenuProgramsPathWindows() (string, error) {
 		}
 		// 在 UserProfile 下的路径可能不同，这里用标准 APPDATA 结构尝试
 		appData = filepath.Join(userProfile, "AppData", "Roaming")
-		util.WarningPrint("APPDATA 环境变量未设置，尝试使用基于 USERPROFILE 的路径:", appData)
+		util.WarningPrint("APPDATA 环境变量未设置，尝试使用基于 USERPROFILE 的路径: %s\n", appData)
 	}
 
 	startMenuPath := filepath.Join(appData, "Microsoft", "Windows", "Start Menu", "Programs")
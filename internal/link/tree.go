@@ -0,0 +1,827 @@
+// internal/link/tree.go
+package link
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"synclink/internal/config"
+	"synclink/internal/journal"
+	"synclink/internal/util"
+)
+
+// CreateTreeLink 以 tree 模式 (类似 GNU stow) 链接文件夹 targetPath：
+// 1. 在 syncDir 下创建名为 linkName 的包目录。
+// 2. 将选中的每个文件移动到包目录中相同的相对位置，并在原处创建指向它的符号链接；
+// targetPath 本身及其中未选中的文件保持不变。
+// 3. 将链接信息 (包括管理的文件列表) 添加到配置中。
+// opts.Files 为空时链接 targetPath 下的全部普通文件。opts.Include、opts.Exclude 和 targetPath 下
+// 忽略规则文件 (IgnoreFileName) 中的规则会进一步筛选文件，被排除的文件留在本机。
+// 开始之前会写入操作日志，中断后可通过 'synclink recover' 向前完成。
+func CreateTreeLink(targetPath, linkName, syncDir string, opts LinkOptions) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	// --- 验证输入 ---
+	absTargetPath, err := util.GetAbsPath(targetPath)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Lstat(absTargetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("目标路径 '%s' 不存在", absTargetPath)
+		}
+		return fmt.Errorf("检查路径 '%s' 时出错: %w", absTargetPath, err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("tree 模式只能用于文件夹，'%s' 不是文件夹", absTargetPath)
+	}
+
+	if _, exists := cfg.GetLink(linkName); exists {
+		return fmt.Errorf("链接名称 '%s' 已存在", linkName)
+	}
+
+	pkgDir := syncedPathFor(syncDir, linkName, true)
+	// 空的包目录可以直接使用 (例如之前一次失败的尝试留下的)
+	if pkgExists, _ := lexists(pkgDir); pkgExists && !isEmptyDir(pkgDir) {
+		return fmt.Errorf("同步目标路径 '%s' 已存在", pkgDir)
+	}
+	keepDir := keptDir(pkgDir) // 第一个文件就失败时，删除本次创建的、位于 keepDir 之下的目录
+	files, excluded, err := selectTreeFiles(absTargetPath, opts.Files, opts)
+	if err != nil {
+		return err
+	}
+	storedSynced, err := storedSyncedPath(cfg, pkgDir, opts)
+	if err != nil {
+		return err
+	}
+	linkInfo := config.LinkInfo{
+		Shortcut:     false,
+		OriginalPath: storedOriginalPath(absTargetPath, opts),
+		SyncedPath:   storedSynced,
+		SyncRoot:     opts.SyncRoot,
+		Kind:         config.KindDir,
+		CreatedAt:    time.Now(),
+		Tags:         opts.Tags,
+		Mode:         config.ModeTree,
+		Files:        files,
+		Include:      opts.Include,
+		Exclude:      opts.Exclude,
+	}
+
+	j, err := beginOp(journal.Entry{
+		Op:           journal.OpCreateTree,
+		LinkName:     linkName,
+		OriginalPath: absTargetPath,
+		SyncedPath:   pkgDir,
+		Info:         linkInfo,
+	})
+	if err != nil {
+		return err
+	}
+
+	// --- 逐个移动和链接 ---
+	report("正在将 '%s' 中的 %d 个文件逐个移动到 '%s' 并创建符号链接...\n", absTargetPath, len(files), pkgDir)
+	if excluded > 0 {
+		report("%d 个文件被排除规则排除，保留在本机。\n", excluded)
+	}
+	madeDirs := make(map[string]bool)
+	for i, rel := range files {
+		src, dst := treeFilePaths(absTargetPath, pkgDir, rel)
+		moved, err := linkTreeFile(j, linkName, src, dst, madeDirs)
+		if err != nil {
+			if i == 0 && !moved {
+				// 还没有移动任何文件：删除为它创建的空目录 (包括包目录)，使重试不会因包目录已存在而失败
+				removeCreatedDirs(filepath.Dir(dst), keepDir)
+				finishOp(j)
+				return err
+			}
+			return fmt.Errorf("%w。%s", err, errRunRecover)
+		}
+	}
+	advanceOp(j, journal.StepLinked)
+
+	// --- 更新配置 ---
+	if err := addLinkToConfig(cfg, linkName, linkInfo); err != nil {
+		return fmt.Errorf("链接已创建 '%s'，但保存配置失败: %w。%s", linkName, err, errRunRecover)
+	}
+
+	recordInManifest(cfg, linkName, linkInfo)
+	finishOp(j)
+
+	report("成功创建并记录 tree 模式链接 '%s' (%d 个文件).\n", linkName, len(files))
+	return nil
+}
+
+// linkTreeFile 将原始位置的文件 src 移动到包目录中的 dst，并在 src 创建指向 dst 的符号链接。
+// moved 表示文件是否已离开原始位置。跨设备复制完成时记录到操作日志 j 中。
+func linkTreeFile(j *journal.Entry, linkName, src, dst string, madeDirs map[string]bool) (moved bool, err error) {
+	if err := ensureDirOnce(linkName, filepath.Dir(dst), madeDirs); err != nil {
+		return false, fmt.Errorf("无法创建目录 '%s': %w", filepath.Dir(dst), err)
+	}
+	if err := perform(Action{Kind: ActionMove, Link: linkName, Path: src, Target: dst, journal: j}); err != nil {
+		dstExists, _ := lexists(dst)
+		return dstExists, fmt.Errorf("移动 '%s' 到 '%s' 失败: %w", src, dst, err)
+	}
+	if err := perform(Action{Kind: ActionSymlink, Link: linkName, Path: src, Target: dst}); err != nil {
+		return true, fmt.Errorf("创建符号链接 '%s' 失败: %w", src, err)
+	}
+	return true, nil
+}
+
+// isEmptyDir 判断 p 是否为空目录。
+func isEmptyDir(p string) bool {
+	entries, err := os.ReadDir(p)
+	return err == nil && len(entries) == 0
+}
+
+// keptDir 返回 dir 最近的一个已存在的祖先目录 (dir 已存在时返回 dir 本身)。
+// 创建 dir 时，位于它之下的目录都是新创建的。
+func keptDir(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if exists, _ := lexists(d); exists || filepath.Dir(d) == d {
+			return d
+		}
+	}
+}
+
+// removeCreatedDirs 从 dir 开始向上删除空目录，直到 keep (不删除 keep 本身)。遇到非空目录时停止。
+func removeCreatedDirs(dir, keep string) {
+	if dryRun {
+		return
+	}
+	for d := dir; isWithin(keep, d); d = filepath.Dir(d) {
+		if err := os.Remove(d); err != nil {
+			return
+		}
+	}
+}
+
+// RestoreTreeLink 从同步目录中已有的包目录恢复 tree 模式链接，用于在新机器上复原链接：
+// 包目录 (syncDir/linkName) 中的每个文件都会在 targetPath 下相同的相对位置创建符号链接，
+// 缺少的父目录会被创建。原始位置上已有的本地副本按照 policy 处理。
+// 与 CreateTreeLink 相同，opts.Include、opts.Exclude 和包目录中忽略规则文件的规则会筛选文件。
+func RestoreTreeLink(targetPath, linkName, syncDir string, policy ConflictPolicy, opts LinkOptions) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	absTargetPath, err := util.GetAbsPath(targetPath)
+	if err != nil {
+		return err
+	}
+	if _, exists := cfg.GetLink(linkName); exists {
+		return fmt.Errorf("链接名称 '%s' 已存在，请使用 'synclink relink %s' 检查并修复现有链接", linkName, linkName)
+	}
+
+	pkgDir := syncedPathFor(syncDir, linkName, true)
+	if isDir, _ := util.IsDir(pkgDir); !isDir {
+		return fmt.Errorf("在同步目录 '%s' 中找不到名为 '%s' 的包目录 '%s'", syncDir, linkName, pkgDir)
+	}
+	if fi, err := os.Lstat(absTargetPath); err == nil && !fi.IsDir() {
+		return fmt.Errorf("原始路径 '%s' 已存在且不是文件夹，tree 模式需要真实的文件夹", absTargetPath)
+	}
+
+	files, _, err := selectTreeFiles(pkgDir, nil, opts)
+	if err != nil {
+		return err
+	}
+	linkInfo, err := restoredLinkInfo(cfg, absTargetPath, pkgDir, opts)
+	if err != nil {
+		return err
+	}
+	linkInfo.Mode = config.ModeTree
+	linkInfo.Files = files
+	linkInfo.Include = opts.Include
+	linkInfo.Exclude = opts.Exclude
+
+	// --- 检查原始位置上的本地副本 ---
+	var localCopies []string
+	for _, rel := range files {
+		original, synced := treeFilePaths(absTargetPath, pkgDir, rel)
+		if _, err := os.Lstat(original); err == nil && !symlinkPointsTo(original, synced) {
+			localCopies = append(localCopies, original)
+		}
+	}
+	if len(localCopies) > 0 && policy != ConflictBackup && policy != ConflictDiscard {
+		return fmt.Errorf("原始位置已存在 %d 个本地副本 (例如 '%s')。请使用 --on-conflict backup 备份它们，或使用 --on-conflict discard 丢弃它们",
+			len(localCopies), localCopies[0])
+	}
+
+	j, err := beginOp(journal.Entry{
+		Op:           journal.OpRestoreTree,
+		LinkName:     linkName,
+		OriginalPath: absTargetPath,
+		SyncedPath:   pkgDir,
+		Policy:       string(policy),
+		Info:         linkInfo,
+	})
+	if err != nil {
+		return err
+	}
+
+	backupSuffix := ".synclink-backup-" + time.Now().Format("20060102-150405")
+	madeDirs := make(map[string]bool)
+	for _, rel := range files {
+		original, synced := treeFilePaths(absTargetPath, pkgDir, rel)
+		if err := restoreTreeFile(linkName, original, synced, policy, backupSuffix, madeDirs); err != nil {
+			return fmt.Errorf("%w。%s", err, errRunRecover)
+		}
+	}
+	advanceOp(j, journal.StepLinked)
+
+	if err := addRestoredLink(cfg, linkName, linkInfo); err != nil {
+		return fmt.Errorf("%w。%s", err, errRunRecover)
+	}
+	finishOp(j)
+	return nil
+}
+
+// restoreTreeFile 按照 policy 处理 original 上的本地副本，然后创建指向 synced 的符号链接。
+// 链接已存在且正确时不做任何事。
+func restoreTreeFile(linkName, original, synced string, policy ConflictPolicy, backupSuffix string, madeDirs map[string]bool) error {
+	if _, err := os.Lstat(original); err == nil {
+		if symlinkPointsTo(original, synced) {
+			return nil
+		}
+		switch policy {
+		case ConflictBackup:
+			backup := original + backupSuffix
+			report("正在将本地副本 '%s' 备份到 '%s'...\n", original, backup)
+			if err := perform(Action{Kind: ActionMove, Link: linkName, Path: original, Target: backup}); err != nil {
+				return fmt.Errorf("备份本地副本 '%s' 失败: %w", original, err)
+			}
+		case ConflictDiscard:
+			report("正在删除本地副本 '%s'...\n", original)
+			if err := perform(Action{Kind: ActionRemoveAll, Link: linkName, Path: original}); err != nil {
+				return fmt.Errorf("删除本地副本 '%s' 失败: %w", original, err)
+			}
+		default:
+			return fmt.Errorf("原始位置 '%s' 已存在本地副本", original)
+		}
+	}
+
+	if err := ensureDirOnce(linkName, filepath.Dir(original), madeDirs); err != nil {
+		return fmt.Errorf("无法创建目录 '%s': %w", filepath.Dir(original), err)
+	}
+	report("正在创建符号链接 '%s' -> '%s'...\n", original, synced)
+	if err := perform(Action{Kind: ActionSymlink, Link: linkName, Path: original, Target: synced}); err != nil {
+		return fmt.Errorf("创建符号链接 '%s' 失败: %w", original, err)
+	}
+	return nil
+}
+
+// removeTreeLink 移除 tree 模式的链接：删除每个文件的符号链接，将文件从包目录移回原始位置，
+// 删除包目录中因此变空的目录，最后移除配置记录。storedInfo 为配置中保存的原始形式，info 为解析后的形式。
+func removeTreeLink(cfg *config.Config, linkName string, storedInfo, info config.LinkInfo) error {
+	if len(info.Files) == 0 {
+		return fmt.Errorf("链接 '%s' 的配置信息不完整 (files 为空)", linkName)
+	}
+
+	// --- 验证状态：任何文件的原始位置被真实数据占用时，在修改之前取消 ---
+	var occupied []string
+	for _, rel := range info.Files {
+		original, synced := treeFilePaths(info.OriginalPath, info.SyncedPath, rel)
+		if _, err := os.Lstat(original); err != nil {
+			continue
+		}
+		if isSymlink, _ := util.IsSymlink(original); !isSymlink {
+			occupied = append(occupied, original)
+		} else if !symlinkPointsTo(original, synced) {
+			util.WarningPrint("符号链接 '%s' 没有指向 '%s'。仍将继续移除。\n", original, synced)
+		}
+	}
+	if len(occupied) > 0 {
+		return fmt.Errorf("以下 %d 个文件的原始位置存在且不是符号链接。为防止数据丢失，取消移除，请手动处理:\n  %s",
+			len(occupied), strings.Join(occupied, "\n  "))
+	}
+
+	j, err := beginOp(journal.Entry{
+		Op:           journal.OpRemoveTree,
+		LinkName:     linkName,
+		OriginalPath: info.OriginalPath,
+		SyncedPath:   info.SyncedPath,
+		Info:         storedInfo,
+	})
+	if err != nil {
+		return err
+	}
+
+	// --- 逐个删除符号链接并移回文件 ---
+	report("正在将 %d 个文件从 '%s' 移回 '%s'...\n", len(info.Files), info.SyncedPath, info.OriginalPath)
+	madeDirs := make(map[string]bool)
+	changed := false
+	for _, rel := range info.Files {
+		original, synced := treeFilePaths(info.OriginalPath, info.SyncedPath, rel)
+		if isSymlink, _ := util.IsSymlink(original); isSymlink {
+			if err := perform(Action{Kind: ActionRemove, Link: linkName, Path: original}); err != nil {
+				if !changed {
+					finishOp(j)
+					return fmt.Errorf("删除符号链接 '%s' 失败: %w", original, err)
+				}
+				return fmt.Errorf("删除符号链接 '%s' 失败: %w。%s", original, err, errRunRecover)
+			}
+			changed = true
+		}
+		if syncedExists, _ := lexists(synced); !syncedExists {
+			util.WarningPrint("包目录中的文件 '%s' 不存在，跳过移回。\n", synced)
+			continue
+		}
+		if err := ensureDirOnce(linkName, filepath.Dir(original), madeDirs); err != nil {
+			return fmt.Errorf("无法创建目录 '%s': %w。%s", filepath.Dir(original), err, errRunRecover)
+		}
+		if err := perform(Action{Kind: ActionMove, Link: linkName, Path: synced, Target: original, journal: j}); err != nil {
+			return fmt.Errorf("无法将 '%s' 移回 '%s': %w。%s", synced, original, err, errRunRecover)
+		}
+	}
+	advanceOp(j, journal.StepMoved)
+	pruneTreeDirs(linkName, info.SyncedPath, info.Files)
+
+	// --- 更新配置 ---
+	removeFromManifest(cfg, linkName, storedInfo)
+
+	removed, err := removeLinkFromConfig(cfg, linkName)
+	if err != nil {
+		return fmt.Errorf("文件已移回，但从配置中移除 '%s' 失败: %w。%s", linkName, err, errRunRecover)
+	}
+	if !removed {
+		util.WarningPrint("尝试移除链接 '%s'，但配置中似乎已不存在。", linkName)
+	}
+	finishOp(j)
+	return nil
+}
+
+// pruneTreeDirs 删除包目录 pkgDir 中只包含 (已移回的) 管理文件的目录，包括 pkgDir 本身。
+// 包目录中的其他文件 (例如其他机器添加的文件) 会被保留。
+func pruneTreeDirs(linkName, pkgDir string, files []string) {
+	gone := make(map[string]bool, len(files))
+	dirSet := map[string]bool{pkgDir: true}
+	for _, rel := range files {
+		p := filepath.Join(pkgDir, filepath.FromSlash(rel))
+		gone[p] = true
+		for d := filepath.Dir(p); isWithin(pkgDir, d); d = filepath.Dir(d) {
+			dirSet[d] = true
+		}
+	}
+	dirs := make([]string, 0, len(dirSet))
+	for d := range dirSet {
+		dirs = append(dirs, d)
+	}
+	// 子目录的路径总是比父目录长，先处理子目录
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		empty := true
+		for _, e := range entries {
+			if !gone[filepath.Join(d, e.Name())] {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			continue
+		}
+		if err := perform(Action{Kind: ActionRemove, Link: linkName, Path: d}); err != nil {
+			util.WarningPrint("删除空目录 '%s' 失败: %v\n", d, err)
+			continue
+		}
+		gone[d] = true
+	}
+}
+
+// relinkTreeLink 检查 tree 模式链接 (已解析) 的每个文件，重新创建丢失或指向错误的符号链接。
+// 包目录中新出现 (例如由其他机器添加) 且符合包含、排除规则的文件也会被链接并记录到配置中。
+// 被真实数据占用的位置和包目录中缺失的文件无法自动修复，会在处理完其他文件后一并报告。
+func relinkTreeLink(cfg *config.Config, linkName string, info config.LinkInfo) error {
+	if len(info.Files) == 0 {
+		return fmt.Errorf("链接 '%s' 的配置信息不完整 (files 为空)", linkName)
+	}
+
+	type fix struct {
+		original, synced string
+		stale            bool // 需要先删除损坏的或指向错误的符号链接
+	}
+	var fixes []fix
+	var problems []string
+	var added []string
+	for _, rel := range newTreeFiles(info) {
+		original, synced := treeFilePaths(info.OriginalPath, info.SyncedPath, rel)
+		if _, err := os.Lstat(original); err == nil {
+			problems = append(problems, fmt.Sprintf("包目录中的新文件 '%s' 在本机已存在 '%s'", synced, original))
+			continue
+		}
+		fixes = append(fixes, fix{original: original, synced: synced})
+		added = append(added, rel)
+	}
+	for _, rel := range info.Files {
+		original, synced := treeFilePaths(info.OriginalPath, info.SyncedPath, rel)
+		if exists, _ := util.PathExists(synced); !exists {
+			problems = append(problems, fmt.Sprintf("包目录中的文件 '%s' 不存在", synced))
+			continue
+		}
+		if _, err := os.Lstat(original); err != nil {
+			fixes = append(fixes, fix{original: original, synced: synced})
+			continue
+		}
+		if isSymlink, _ := util.IsSymlink(original); !isSymlink {
+			problems = append(problems, fmt.Sprintf("'%s' 存在但不是符号链接", original))
+			continue
+		}
+		if !symlinkPointsTo(original, synced) {
+			fixes = append(fixes, fix{original: original, synced: synced, stale: true})
+		}
+	}
+
+	if len(fixes) > 0 {
+		j, err := beginOp(journal.Entry{
+			Op:           journal.OpRelink,
+			LinkName:     linkName,
+			OriginalPath: info.OriginalPath,
+			SyncedPath:   info.SyncedPath,
+		})
+		if err != nil {
+			return err
+		}
+		defer finishOp(j) // 与 RelinkSymbolicLink 相同：中断后再次 relink 即可修复
+
+		madeDirs := make(map[string]bool)
+		for _, f := range fixes {
+			if f.stale {
+				if err := perform(Action{Kind: ActionRemove, Link: linkName, Path: f.original}); err != nil {
+					return fmt.Errorf("无法移除指向错误的符号链接 '%s'，重新链接失败: %w", f.original, err)
+				}
+			}
+			if err := ensureDirOnce(linkName, filepath.Dir(f.original), madeDirs); err != nil {
+				return fmt.Errorf("无法创建目录 '%s': %w", filepath.Dir(f.original), err)
+			}
+			report("正在重新创建符号链接 '%s' -> '%s'...\n", f.original, f.synced)
+			if err := perform(Action{Kind: ActionSymlink, Link: linkName, Path: f.original, Target: f.synced}); err != nil {
+				return fmt.Errorf("重新创建符号链接 '%s' 失败: %w", f.original, err)
+			}
+		}
+		report("已重新链接 %d 个文件.\n", len(fixes))
+	}
+
+	if len(added) > 0 {
+		desc := fmt.Sprintf("在配置中为链接 '%s' 记录 %d 个新文件", linkName, len(added))
+		err := updateConfig(linkName, desc, func() error {
+			stored, ok := cfg.GetLink(linkName)
+			if !ok {
+				return fmt.Errorf("链接 '%s' 未在配置中找到", linkName)
+			}
+			stored.Files = append(append([]string(nil), stored.Files...), added...)
+			sort.Strings(stored.Files)
+			return cfg.AddLink(linkName, stored)
+		})
+		if err != nil {
+			return fmt.Errorf("新文件已链接，但更新链接 '%s' 的配置失败: %w", linkName, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d 个文件无法重新链接，请手动处理:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// newTreeFiles 返回包目录中存在、符合链接的包含和排除规则，但尚未记录在 Files 中的文件。
+func newTreeFiles(info config.LinkInfo) []string {
+	filter, err := loadFileFilter(info.SyncedPath, info.Include, info.Exclude)
+	if err != nil {
+		util.WarningPrint("%v\n", err)
+		return nil
+	}
+	all, err := collectTreeFiles(info.SyncedPath, nil)
+	if err != nil {
+		return nil // 包目录不存在或为空：已记录的文件会在检查时报告
+	}
+	known := make(map[string]bool, len(info.Files))
+	for _, rel := range info.Files {
+		known[rel] = true
+	}
+	var files []string
+	for _, rel := range all {
+		if !known[rel] && filter.match(rel) {
+			files = append(files, rel)
+		}
+	}
+	return files
+}
+
+// checkTree 检查 (已解析的) tree 模式链接：包目录中的每个文件是否存在，
+// 原始位置的对应文件是否为指向它的符号链接。返回第一个异常文件的状态。
+func checkTree(info config.LinkInfo) (Health, string) {
+	if info.OriginalPath == "" || info.SyncedPath == "" {
+		return HealthBroken, "配置信息不完整 (original_path 或 synced_path 为空)"
+	}
+	if len(info.Files) == 0 {
+		return HealthBroken, "配置信息不完整 (files 为空)"
+	}
+	if isDir, _ := util.IsDir(info.SyncedPath); !isDir {
+		return HealthMissingData, fmt.Sprintf("包目录 '%s' 不存在", info.SyncedPath)
+	}
+
+	status, detail := HealthOK, ""
+	bad := 0
+	for _, rel := range info.Files {
+		original, synced := treeFilePaths(info.OriginalPath, info.SyncedPath, rel)
+		h, d := checkTreeFile(original, synced)
+		if h == HealthOK {
+			continue
+		}
+		if bad == 0 {
+			status, detail = h, d
+		}
+		bad++
+	}
+	if bad > 1 {
+		detail = fmt.Sprintf("%s (共 %d 个文件异常)", detail, bad)
+	}
+	return status, detail
+}
+
+// checkTreeFile 检查 tree 模式链接中的一个文件。
+func checkTreeFile(original, synced string) (Health, string) {
+	syncedInfo, err := os.Stat(synced)
+	if err != nil {
+		return HealthMissingData, fmt.Sprintf("同步数据 '%s' 不存在", synced)
+	}
+	if syncedInfo.IsDir() {
+		return HealthDrifted, fmt.Sprintf("同步数据 '%s' 应为文件，实际是文件夹", synced)
+	}
+	if _, err := os.Lstat(original); err != nil {
+		return HealthBroken, fmt.Sprintf("符号链接 '%s' 不存在", original)
+	}
+	if isSymlink, _ := util.IsSymlink(original); !isSymlink {
+		return HealthConflict, fmt.Sprintf("'%s' 存在但不是符号链接", original)
+	}
+	target, err := os.Readlink(original)
+	if err != nil {
+		return HealthBroken, fmt.Sprintf("无法读取符号链接 '%s' 的目标: %v", original, err)
+	}
+	if !util.SamePath(target, synced) {
+		return HealthDrifted, fmt.Sprintf("符号链接 '%s' 指向 '%s'，而不是 '%s'", original, target, synced)
+	}
+	return HealthOK, ""
+}
+
+// treeApplyState 判断 (已解析的) tree 模式链接是否已在本机应用：所有文件都已链接时为 applied，
+// 任何文件的位置被其他数据占用时为 conflicting，否则为 pending。
+func treeApplyState(info config.LinkInfo) ApplyState {
+	files := info.Files
+	if len(files) == 0 {
+		// 仅存在于清单中的链接没有文件列表，由包目录的内容决定
+		var err error
+		if files, err = collectTreeFiles(info.SyncedPath, nil); err != nil {
+			return StatePending
+		}
+	}
+	applied := 0
+	for _, rel := range files {
+		original, synced := treeFilePaths(info.OriginalPath, info.SyncedPath, rel)
+		if _, err := os.Lstat(original); err != nil {
+			continue
+		}
+		if !symlinkPointsTo(original, synced) {
+			return StateConflicting
+		}
+		applied++
+	}
+	if applied == len(files) {
+		return StateApplied
+	}
+	return StatePending
+}
+
+// collectTreeFiles 列出目录 root 下要逐个链接的普通文件，返回相对于 root、使用 '/' 分隔的路径 (已排序)。
+// selection 为空时选择全部文件；否则只选择其中列出的相对路径 (文件，或其下全部文件的文件夹)。
+// 符号链接等特殊条目会被跳过。
+func collectTreeFiles(root string, selection []string) ([]string, error) {
+	starts := []string{"."}
+	if len(selection) > 0 {
+		starts = starts[:0]
+		for _, sel := range selection {
+			rel := filepath.Clean(filepath.FromSlash(sel))
+			if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return nil, fmt.Errorf("'%s' 必须是相对于 '%s' 的路径", sel, root)
+			}
+			starts = append(starts, rel)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, start := range starts {
+		startPath := filepath.Join(root, start)
+		fi, err := os.Lstat(startPath)
+		if err != nil {
+			return nil, fmt.Errorf("无法访问 '%s': %w", startPath, err)
+		}
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			return nil, fmt.Errorf("'%s' 不是常规文件或文件夹，无法逐个链接", startPath)
+		}
+		err = filepath.WalkDir(startPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("遍历 '%s' 时出错: %w", p, err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				util.WarningPrint("跳过 '%s'：只有普通文件可以逐个链接。\n", p)
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			if rel = filepath.ToSlash(rel); !seen[rel] {
+				seen[rel] = true
+				files = append(files, rel)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("'%s' 中没有可以链接的文件", root)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// selectTreeFiles 列出目录 root 下 (或 selection 中) 的普通文件，并按 opts 中的规则和 root 下的忽略规则文件筛选。
+// 返回要链接的文件和被排除的文件数；没有剩下任何文件时返回错误。
+func selectTreeFiles(root string, selection []string, opts LinkOptions) (files []string, excluded int, err error) {
+	if err := ValidatePatterns(append(append([]string(nil), opts.Include...), opts.Exclude...)); err != nil {
+		return nil, 0, err
+	}
+	files, err = collectTreeFiles(root, selection)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter, err := loadFileFilter(root, opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, 0, err
+	}
+	files, excluded = filter.apply(files)
+	if len(files) == 0 {
+		return nil, excluded, fmt.Errorf("'%s' 中的 %d 个文件都被排除规则排除，没有可以链接的文件", root, excluded)
+	}
+	return files, excluded, nil
+}
+
+// treeFilePaths 返回 tree 模式链接中的一个文件 (rel 使用 '/' 分隔) 在原始位置和包目录中的路径。
+func treeFilePaths(originalDir, pkgDir, rel string) (original, synced string) {
+	rel = filepath.FromSlash(rel)
+	return filepath.Join(originalDir, rel), filepath.Join(pkgDir, rel)
+}
+
+// ensureDirOnce 与 ensureDir 相同，但同一目录及其父目录只处理一次，
+// 避免逐个处理文件时 (尤其是 dry-run 模式下) 重复产生 mkdir 步骤。
+func ensureDirOnce(linkName, dir string, made map[string]bool) error {
+	if made[dir] {
+		return nil
+	}
+	if err := ensureDir(linkName, dir); err != nil {
+		return err
+	}
+	for d := dir; !made[d]; d = filepath.Dir(d) {
+		made[d] = true
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return nil
+}
+
+// recoverCreateTree 处理被中断的 tree 模式链接创建：逐个文件向前完成移动和链接，然后补充配置记录。
+func recoverCreateTree(cfg *config.Config, e *journal.Entry) error {
+	for _, rel := range e.Info.Files {
+		original, synced := treeFilePaths(e.OriginalPath, e.SyncedPath, rel)
+		if err := removePartialCopy(synced); err != nil {
+			return err
+		}
+		syncedExists, err := lexists(synced)
+		if err != nil {
+			return err
+		}
+		originalExists, err := lexists(original)
+		if err != nil {
+			return err
+		}
+		originalIsLink, _ := util.IsSymlink(original)
+
+		switch {
+		case !syncedExists && originalExists && !originalIsLink:
+			fmt.Printf("正在移动 '%s' 到 '%s'...\n", original, synced)
+			if err := util.EnsureDirExists(filepath.Dir(synced)); err != nil {
+				return err
+			}
+			if err := moveData(original, synced, e); err != nil {
+				return fmt.Errorf("移动 '%s' 到 '%s' 失败: %w", original, synced, err)
+			}
+		case !syncedExists:
+			return fmt.Errorf("原始位置 '%s' 和包目录 '%s' 中都找不到文件，请手动检查", original, synced)
+		case originalExists && !originalIsLink:
+			if !e.Copied(synced) {
+				if e.Step == journal.StepLinked {
+					return fmt.Errorf("原始位置 '%s' 被其他数据占用，无法完成链接，请手动处理", original)
+				}
+				return unconfirmedCopyError(original, synced)
+			}
+			// 日志记录了完整副本已重命名到包目录，删除源文件时被中断：剩余部分是多余的
+			fmt.Printf("文件已完整复制到 '%s'，正在删除原始位置残留的 '%s'...\n", synced, original)
+			if err := os.RemoveAll(original); err != nil {
+				return fmt.Errorf("删除残留数据 '%s' 失败: %w", original, err)
+			}
+		}
+
+		if err := ensureSymlink(e.LinkName, original, synced); err != nil {
+			return err
+		}
+	}
+	return ensureRecorded(cfg, e)
+}
+
+// recoverRemoveTree 处理被中断的 tree 模式链接移除：总是向前完成，把文件逐个移回原始位置并移除记录。
+func recoverRemoveTree(cfg *config.Config, e *journal.Entry) error {
+	for _, rel := range e.Info.Files {
+		original, synced := treeFilePaths(e.OriginalPath, e.SyncedPath, rel)
+		if isLink, _ := util.IsSymlink(original); isLink {
+			fmt.Printf("正在删除符号链接 '%s'...\n", original)
+			if err := os.Remove(original); err != nil {
+				return fmt.Errorf("删除符号链接 '%s' 失败: %w", original, err)
+			}
+		}
+		if err := removePartialCopy(original); err != nil {
+			return err
+		}
+		syncedExists, err := lexists(synced)
+		if err != nil {
+			return err
+		}
+		originalExists, err := lexists(original)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case syncedExists && originalExists:
+			if !e.Copied(original) {
+				return unconfirmedCopyError(synced, original)
+			}
+			// 日志记录了完整副本已移回原始位置，删除包目录中的文件时被中断
+			fmt.Printf("文件已完整移回 '%s'，正在删除包目录中残留的 '%s'...\n", original, synced)
+			if err := os.RemoveAll(synced); err != nil {
+				return fmt.Errorf("删除残留数据 '%s' 失败: %w", synced, err)
+			}
+		case syncedExists:
+			fmt.Printf("正在将 '%s' 移回 '%s'...\n", synced, original)
+			if err := util.EnsureDirExists(filepath.Dir(original)); err != nil {
+				return err
+			}
+			if err := moveData(synced, original, e); err != nil {
+				return fmt.Errorf("无法将 '%s' 移回 '%s': %w", synced, original, err)
+			}
+		}
+	}
+	pruneTreeDirs(e.LinkName, e.SyncedPath, e.Info.Files)
+
+	removeFromManifest(cfg, e.LinkName, e.Info)
+	if _, err := cfg.RemoveLink(e.LinkName); err != nil {
+		return fmt.Errorf("从配置中移除 '%s' 失败: %w", e.LinkName, err)
+	}
+	return nil
+}
+
+// recoverRestoreTree 处理被中断的 tree 模式链接恢复：逐个文件按照记录的冲突策略向前完成，然后补充配置记录。
+func recoverRestoreTree(cfg *config.Config, e *journal.Entry) error {
+	backupSuffix := ".synclink-backup-" + time.Now().Format("20060102-150405")
+	madeDirs := make(map[string]bool)
+	for _, rel := range e.Info.Files {
+		original, synced := treeFilePaths(e.OriginalPath, e.SyncedPath, rel)
+		if exists, _ := lexists(synced); !exists {
+			return fmt.Errorf("包目录中的文件 '%s' 不存在，无法完成恢复，请手动检查", synced)
+		}
+		if err := restoreTreeFile(e.LinkName, original, synced, ConflictPolicy(e.Policy), backupSuffix, madeDirs); err != nil {
+			return err
+		}
+	}
+	return ensureRecorded(cfg, e)
+}
@@ -0,0 +1,136 @@
+// internal/manifest/manifest.go
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"synclink/internal/util"
+)
+
+// FileName 是清单文件在同步根目录中的名称。
+const FileName = "synclink.manifest.json"
+
+// CurrentVersion 是清单文件结构的当前版本。
+const CurrentVersion = "1.0"
+
+// Entry 描述一个期望存在的链接。清单随同步数据一起分发到每台机器，
+// 因此这里只保存与机器无关的信息：原始路径 (通常带环境变量令牌) 和相对于清单所在目录的同步路径。
+// 创建时间、是否已在本机应用、解析后的绝对路径等属于本机状态，保存在本地的 config.json 中。
+type Entry struct {
+	OriginalPath string `json:"original_path"`  // 原始位置，可能包含环境变量令牌
+	SyncedPath   string `json:"synced_path"`    // 相对于清单所在目录的同步路径，使用 '/' 分隔
+	Mode         string `json:"mode,omitempty"` // 链接模式，与 config.LinkInfo.Mode 相同；tree 模式管理的文件由包目录的内容决定
+}
+
+// Manifest 是保存在同步根目录中的共享链接清单。
+type Manifest struct {
+	Version string           `json:"version"`
+	Links   map[string]Entry `json:"links"` // 键是链接名称
+
+	dir string // 清单所在的目录 (不序列化)
+}
+
+// Path 返回目录 dir 下清单文件的路径。
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load 读取目录 dir 下的清单。如果清单文件不存在，返回一个空清单而不是错误。
+func Load(dir string) (*Manifest, error) {
+	m := &Manifest{
+		Version: CurrentVersion,
+		Links:   make(map[string]Entry),
+		dir:     dir,
+	}
+
+	data, err := os.ReadFile(Path(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("读取清单文件 '%s' 失败: %w", Path(dir), err)
+	}
+	if len(data) == 0 {
+		return m, nil
+	}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("解析清单文件 '%s' 失败: %w", Path(dir), err)
+	}
+	if m.Links == nil {
+		m.Links = make(map[string]Entry)
+	}
+	m.dir = dir
+	return m, nil
+}
+
+// Dir 返回清单所在的目录。
+func (m *Manifest) Dir() string {
+	return m.dir
+}
+
+// Put 添加或更新一个条目。
+func (m *Manifest) Put(name string, e Entry) {
+	m.Links[name] = e
+}
+
+// Remove 移除一个条目。如果条目存在并被移除，则返回 true。
+func (m *Manifest) Remove(name string) bool {
+	if _, ok := m.Links[name]; !ok {
+		return false
+	}
+	delete(m.Links, name)
+	return true
+}
+
+// ResolvedSyncedPath 返回条目在本机上的同步路径绝对形式。
+func (m *Manifest) ResolvedSyncedPath(e Entry) string {
+	return filepath.Join(m.dir, filepath.FromSlash(e.SyncedPath))
+}
+
+// Save 将清单写回其所在目录。清单位于同步目录中，同步软件随时可能读取它，因此以原子方式写入，
+// 不会让其他机器同步到只写了一半的文件。需要基于最新内容修改清单时请使用 Update。
+func (m *Manifest) Save() error {
+	if err := util.EnsureDirExists(m.dir); err != nil {
+		return err
+	}
+	m.Version = CurrentVersion
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("将清单序列化为 JSON 失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(Path(m.dir), data, 0644); err != nil {
+		return fmt.Errorf("写入清单文件 '%s' 失败: %w", Path(m.dir), err)
+	}
+	return nil
+}
+
+// Update 在持有锁文件 lockPath 的情况下重新读取目录 dir 下的清单，调用 fn 修改它，fn 返回 true 时写回。
+// 读取和写入之间不会插入其他 synclink 进程对同一清单的写入，因此它们添加或移除的条目不会被覆盖。
+// 锁文件应位于本机 (而不是同步目录中)：它只能防止本机进程之间的冲突，其他机器通过同步软件写入的修改
+// 无法被锁住，但每次修改都基于最新的内容，只有几乎同时的写入才会互相覆盖。
+func Update(dir, lockPath string, timeout time.Duration, fn func(m *Manifest) bool) error {
+	if err := util.EnsureDirExists(filepath.Dir(lockPath)); err != nil {
+		return err
+	}
+	lock, err := util.LockFile(lockPath, timeout, func(holder string) {
+		util.WarningPrint("另一个 synclink 进程正在修改清单 '%s'，等待其完成...\n", Path(dir))
+	})
+	if err != nil {
+		return fmt.Errorf("无法锁定清单文件 '%s': %w", Path(dir), err)
+	}
+	defer lock.Unlock()
+
+	m, err := Load(dir)
+	if err != nil {
+		return err
+	}
+	if !fn(m) {
+		return nil
+	}
+	return m.Save()
+}
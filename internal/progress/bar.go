@@ -0,0 +1,141 @@
+// internal/progress/bar.go
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"synclink/internal/util"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	barWidth       = 30
+	redrawInterval = 100 * time.Millisecond
+)
+
+// Bar 在终端的同一行上渲染复制进度：进度条、百分比、已复制大小、文件数、速度和剩余时间。
+// 复制完成后进入校验阶段 (Progress.Verifying) 时，会换行并以 "正在校验" 重新开始计时。
+// 标准输出不是终端 (例如被重定向到文件或管道) 时不输出任何内容。
+// 第一次收到进度之前也不输出，因此同一文件系统内瞬间完成的移动不会显示进度条。
+type Bar struct {
+	label   string
+	out     io.Writer
+	enabled bool
+
+	verifying bool
+	start     time.Time
+	last      time.Time
+	drawn     bool
+	lastLen   int
+}
+
+// New 创建一个输出到标准输出的进度条，label 显示在进度条前面。
+func New(label string) *Bar {
+	fd := os.Stdout.Fd()
+	return &Bar{
+		label:   label,
+		out:     os.Stdout,
+		enabled: isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd),
+	}
+}
+
+// Update 接收最新进度并按需重绘，可直接作为 util.ProgressFunc 使用。
+func (b *Bar) Update(p util.Progress) {
+	if !b.enabled {
+		return
+	}
+	now := time.Now()
+	if p.Verifying != b.verifying {
+		b.Finish()
+		b.verifying = p.Verifying
+		b.start = time.Time{}
+	}
+	if b.start.IsZero() {
+		b.start = now
+	}
+	finished := p.BytesDone >= p.BytesTotal && p.FilesDone >= p.FilesTotal
+	if b.drawn && !finished && now.Sub(b.last) < redrawInterval {
+		return
+	}
+	b.last = now
+	b.draw(p, now.Sub(b.start))
+}
+
+// Finish 结束进度条所在的行。没有绘制过进度条时不输出任何内容。
+func (b *Bar) Finish() {
+	if b.drawn {
+		fmt.Fprintln(b.out)
+		b.drawn = false
+		b.lastLen = 0
+	}
+}
+
+func (b *Bar) draw(p util.Progress, elapsed time.Duration) {
+	ratio := 1.0
+	if p.BytesTotal > 0 {
+		ratio = float64(p.BytesDone) / float64(p.BytesTotal)
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(ratio * barWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+
+	speed, eta := "--", "--:--"
+	if secs := elapsed.Seconds(); secs > 0 && p.BytesDone > 0 {
+		rate := float64(p.BytesDone) / secs
+		speed = FormatBytes(int64(rate)) + "/s"
+		eta = formatDuration(time.Duration(float64(p.BytesTotal-p.BytesDone) / rate * float64(time.Second)))
+	}
+
+	label := b.label
+	if b.verifying {
+		label = "正在校验"
+	}
+	line := fmt.Sprintf("%s [%s] %5.1f%%  %s/%s  %d/%d 个文件  %s  剩余 %s",
+		label, bar, ratio*100, FormatBytes(p.BytesDone), FormatBytes(p.BytesTotal),
+		p.FilesDone, p.FilesTotal, speed, eta)
+
+	// 用空格覆盖上一次输出中更长的部分
+	padding := ""
+	if n := len(line); n < b.lastLen {
+		padding = strings.Repeat(" ", b.lastLen-n)
+	}
+	b.lastLen = len(line)
+	fmt.Fprintf(b.out, "\r%s%s", line, padding)
+	b.drawn = true
+}
+
+// FormatBytes 将字节数格式化为便于阅读的形式 (例如 1.5 GB)。
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDuration 将剩余时间格式化为 mm:ss 或 h:mm:ss。
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	if s < 0 {
+		s = 0
+	}
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s%3600/60, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package util
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDeviceError 判断 os.Rename 返回的错误是否为跨文件系统移动导致。
+// Linux/macOS 上对应 EXDEV。
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
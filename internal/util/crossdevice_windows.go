@@ -0,0 +1,16 @@
+//go:build windows
+
+package util
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// isCrossDeviceError 判断 os.Rename 返回的错误是否为跨磁盘移动导致。
+// Windows 上通常表现为 ERROR_NOT_SAME_DEVICE，部分情况下也会被转换为 EXDEV。
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV) || errors.Is(err, windows.ERROR_NOT_SAME_DEVICE)
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errLocked 由平台相关的 tryLockFile 返回，表示锁正被其他进程持有。
+var errLocked = errors.New("文件已被锁定")
+
+// FileLock 是基于操作系统建议锁 (Linux/macOS 上的 flock，Windows 上的 LockFileEx) 的跨进程互斥锁。
+// 持有锁的进程退出 (包括崩溃) 时，操作系统会自动释放锁，因此不会留下需要手动删除的陈旧锁。
+type FileLock struct {
+	f *os.File
+}
+
+// LockFile 获取锁文件 path 上的排他锁，必要时创建该文件。锁正被其他进程持有时，
+// 先调用一次 onWait (参数为持有者记录在锁文件中的 PID，可能为空)，然后每隔一小段时间重试，
+// 超过 timeout 仍未获得锁时返回错误。
+func LockFile(path string, timeout time.Duration, onWait func(holder string)) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件 '%s' 失败: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close()
+			return nil, fmt.Errorf("锁定 '%s' 失败: %w", path, err)
+		}
+		holder := lockHolder(path)
+		if time.Now().After(deadline) {
+			f.Close()
+			if holder != "" {
+				return nil, fmt.Errorf("等待 %s 超时：进程 %s 仍持有锁文件 '%s'", timeout, holder, path)
+			}
+			return nil, fmt.Errorf("等待 %s 超时：另一个进程仍持有锁文件 '%s'", timeout, path)
+		}
+		if !waiting && onWait != nil {
+			waiting = true
+			onWait(holder)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// 记录持有者的 PID，供等待的进程提示用户。写入失败不影响锁本身
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return &FileLock{f: f}, nil
+}
+
+// Unlock 释放锁。锁文件本身保留，删除它会让等待中的进程锁住一个已被移除的文件。
+func (l *FileLock) Unlock() error {
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// lockHolder 返回锁文件中记录的持有者 PID，读取失败时返回空字符串。
+func lockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,28 @@
+//go:build solaris || illumos || aix
+
+package util
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile 尝试以非阻塞方式获取 f 上的 fcntl 排他锁 (这些系统没有 flock)。
+// fcntl 锁属于进程而不是文件描述符，因此只能排斥其他进程，这与锁的用途一致。
+func tryLockFile(f *os.File) error {
+	lk := unix.Flock_t{Type: unix.F_WRLCK, Whence: io.SeekStart}
+	err := unix.FcntlFlock(f.Fd(), unix.F_SETLK, &lk)
+	if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EACCES) {
+		return errLocked
+	}
+	return err
+}
+
+// unlockFile 释放 f 上的 fcntl 锁。
+func unlockFile(f *os.File) error {
+	lk := unix.Flock_t{Type: unix.F_UNLCK, Whence: io.SeekStart}
+	return unix.FcntlFlock(f.Fd(), unix.F_SETLK, &lk)
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package util
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffsetHigh 是被锁定字节的偏移量 (高 32 位)。Windows 的锁是强制性的，
+// 锁定远超文件末尾的一个字节，其他进程仍能读取锁文件中记录的 PID。
+const lockOffsetHigh = 0x7FFFFFFF
+
+// tryLockFile 尝试以非阻塞方式获取 f 上的 LockFileEx 排他锁。
+func tryLockFile(f *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLocked
+	}
+	return err
+}
+
+// unlockFile 释放 f 上的 LockFileEx 锁。
+func unlockFile(f *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID 唯一标识文件系统中的一个文件，用于识别互为硬链接的路径。
+type fileID struct {
+	dev, ino uint64
+}
+
+// hardlinkID 返回 path 的文件标识和硬链接数。链接数不大于 1 表示不是硬链接。
+// Linux/macOS 上使用 lstat 返回的设备号和 inode。
+func hardlinkID(path string) (fileID, uint64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fileID{}, 0, fmt.Errorf("无法获取文件 '%s' 的信息: %w", path, err)
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, 1, nil
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, uint64(st.Nlink), nil
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileID 唯一标识文件系统中的一个文件，用于识别互为硬链接的路径。
+type fileID struct {
+	volume    uint32
+	indexHigh uint32
+	indexLow  uint32
+}
+
+// hardlinkID 返回 path 的文件标识和硬链接数。链接数不大于 1 表示不是硬链接。
+// Windows 上使用 GetFileInformationByHandle 返回的卷序列号和文件索引。
+func hardlinkID(path string) (fileID, uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileID{}, 0, fmt.Errorf("无法打开文件 '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	var d windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(windows.Handle(f.Fd()), &d); err != nil {
+		return fileID{}, 0, fmt.Errorf("无法获取文件 '%s' 的信息: %w", path, err)
+	}
+	return fileID{volume: d.VolumeSerialNumber, indexHigh: d.FileIndexHigh, indexLow: d.FileIndexLow}, uint64(d.NumberOfLinks), nil
+}
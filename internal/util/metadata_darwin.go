@@ -0,0 +1,23 @@
+//go:build darwin
+
+package util
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// fileAtime 返回文件的访问时间。
+func fileAtime(info fs.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atimespec.Sec, st.Atimespec.Nsec), true
+}
+
+// copyXattrs 在 macOS 上不复制扩展属性。
+func copyXattrs(src, dst string) error {
+	return nil
+}
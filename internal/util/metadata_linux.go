@@ -0,0 +1,85 @@
+//go:build linux
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileAtime 返回文件的访问时间。
+func fileAtime(info fs.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atim.Sec, st.Atim.Nsec), true
+}
+
+// copyXattrs 将 src 的扩展属性复制到 dst (不跟随符号链接)。
+// 文件系统不支持扩展属性时视为没有可复制的属性；无法设置的属性名会在错误中列出。
+func copyXattrs(src, dst string) error {
+	names, err := listXattrs(src)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil
+		}
+		return fmt.Errorf("读取扩展属性失败: %w", err)
+	}
+
+	var failed []string
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err != nil {
+			failed = append(failed, name)
+			continue
+		}
+		if err := unix.Lsetxattr(dst, name, value, 0); err != nil {
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("无法复制扩展属性 %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// listXattrs 返回 path 上所有扩展属性的名称。
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// getXattr 读取 path 上名为 name 的扩展属性的值。
+func getXattr(path, name string) ([]byte, error) {
+	size, err := unix.Lgetxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Lgetxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
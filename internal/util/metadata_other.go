@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package util
+
+import (
+	"io/fs"
+	"time"
+)
+
+// fileAtime 在其他系统上不读取访问时间，调用者改用修改时间。
+func fileAtime(info fs.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// copyXattrs 在其他系统上不复制扩展属性。
+func copyXattrs(src, dst string) error {
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package util
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// fileAtime 返回文件的访问时间。
+func fileAtime(info fs.FileInfo) (time.Time, bool) {
+	d, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, d.LastAccessTime.Nanoseconds()), true
+}
+
+// copyOwner 在 Windows 上不复制所有者：新文件继承目标目录的 ACL。
+func copyOwner(dst string, info fs.FileInfo) error {
+	return nil
+}
+
+// copyXattrs 在 Windows 上没有扩展属性需要复制。
+func copyXattrs(src, dst string) error {
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// copyOwner 将 dst 的所有者设为与 info 相同 (不跟随符号链接)。
+// 所有者已经相同 (通常如此) 时不做任何事；非 root 用户无法更改为其他用户时返回错误。
+func copyOwner(dst string, info fs.FileInfo) error {
+	want, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	current, err := os.Lstat(dst)
+	if err != nil {
+		return err
+	}
+	if got, ok := current.Sys().(*syscall.Stat_t); ok && got.Uid == want.Uid && got.Gid == want.Gid {
+		return nil
+	}
+	if err := os.Lchown(dst, int(want.Uid), int(want.Gid)); err != nil {
+		return fmt.Errorf("无法将所有者设为 %d:%d: %w", want.Uid, want.Gid, err)
+	}
+	return nil
+}
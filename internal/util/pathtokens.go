@@ -0,0 +1,137 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// portableTokens 列出 ContractPath 在当前平台上会尝试使用的环境变量令牌。
+// 顺序无关紧要：ContractPath 总是选择匹配最长前缀的那个。
+func portableTokens() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"%LOCALAPPDATA%", "%APPDATA%", "%PROGRAMDATA%", "%USERPROFILE%"}
+	}
+	return []string{"$XDG_CONFIG_HOME", "$XDG_DATA_HOME", "$HOME"}
+}
+
+// lookupPathVar 查找环境变量的值。
+// 对于 XDG 目录变量，未设置时按照 XDG Base Directory 规范回退到 $HOME 下的默认位置，
+// 这样在一台机器上记录的 $XDG_CONFIG_HOME 在另一台未设置该变量的机器上仍然可以展开。
+func lookupPathVar(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok && v != "" {
+		return v, true
+	}
+	if runtime.GOOS != "windows" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		switch name {
+		case "HOME":
+			return home, true
+		case "XDG_CONFIG_HOME":
+			return filepath.Join(home, ".config"), true
+		case "XDG_DATA_HOME":
+			return filepath.Join(home, ".local", "share"), true
+		}
+	}
+	return "", false
+}
+
+// ExpandPath 展开路径开头的环境变量令牌。只识别 ContractPath 会生成的令牌 (见 portableTokens)，
+// 并且令牌必须是路径的第一个部分，例如 $HOME/.config 或 %LOCALAPPDATA%\uv。
+// 路径其他位置的 %VAR%、$VAR 可能是真实文件夹名称的一部分，保持原样；
+// 使用 --absolute 保存的绝对路径不以令牌开头，因此也不会被改写。无法展开时原样返回。
+func ExpandPath(p string) string {
+	for _, token := range portableTokens() {
+		rest, ok := cutTokenPrefix(p, token)
+		if !ok {
+			continue
+		}
+		value, ok := lookupPathVar(strings.Trim(token, "%$"))
+		if !ok {
+			return p
+		}
+		return filepath.Join(value, rest)
+	}
+	return p
+}
+
+// cutTokenPrefix 判断 p 是否以令牌 token 作为第一个路径部分 (也接受 ${VAR} 形式)，并返回令牌之后的部分。
+// Windows 上的令牌不区分大小写。
+func cutTokenPrefix(p, token string) (string, bool) {
+	forms := []string{token}
+	if strings.HasPrefix(token, "$") {
+		forms = append(forms, "${"+token[1:]+"}")
+	}
+	for _, form := range forms {
+		if len(p) < len(form) {
+			continue
+		}
+		head := p[:len(form)]
+		if runtime.GOOS == "windows" {
+			if !strings.EqualFold(head, form) {
+				continue
+			}
+		} else if head != form {
+			continue
+		}
+		if len(p) == len(form) || os.IsPathSeparator(p[len(form)]) || p[len(form)] == '/' {
+			return p[len(form):], true
+		}
+	}
+	return "", false
+}
+
+// ContractPath 将绝对路径中匹配的用户目录前缀替换为环境变量令牌 (例如 %LOCALAPPDATA% 或 $HOME)，
+// 使记录下来的路径可以在其他机器或其他账户上使用。没有匹配的令牌时原样返回。
+func ContractPath(p string) string {
+	p = filepath.Clean(p)
+
+	bestToken, bestPrefix := "", ""
+	for _, token := range portableTokens() {
+		name := strings.Trim(token, "%$")
+		value, ok := lookupPathVar(name)
+		if !ok || !filepath.IsAbs(value) {
+			continue
+		}
+		value = filepath.Clean(value)
+		if !hasPathPrefix(p, value) || len(value) <= len(bestPrefix) {
+			continue
+		}
+		bestToken, bestPrefix = token, value
+	}
+
+	if bestToken == "" {
+		return p
+	}
+	return bestToken + p[len(bestPrefix):]
+}
+
+// hasPathPrefix 判断 p 是否等于 prefix 或位于 prefix 目录之下。
+// Windows 上的路径比较不区分大小写。
+func hasPathPrefix(p, prefix string) bool {
+	if len(p) < len(prefix) {
+		return false
+	}
+	head := p[:len(prefix)]
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(head, prefix) {
+			return false
+		}
+	} else if head != prefix {
+		return false
+	}
+	return len(p) == len(prefix) || os.IsPathSeparator(p[len(prefix)]) || os.IsPathSeparator(prefix[len(prefix)-1])
+}
+
+// SamePath 判断两个路径在词法上是否相同 (不访问文件系统)。Windows 上不区分大小写。
+func SamePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -1,17 +1,15 @@
 package util
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
-	"syscall" // 用于检查跨设备链接错误
 
 	"github.com/fatih/color"
-	"golang.org/x/sys/windows"
 )
 
 // ConfigFileName 是配置文件的默认名称
@@ -43,8 +41,53 @@ func GetExecutableDir() (string, error) {
 	return filepath.Dir(exePath), nil
 }
 
-// GetConfigPath 返回配置文件的绝对路径 (默认在可执行文件同目录下)。
-func GetConfigPath() (string, error) {
+// ConfigPathEnv 是指定配置文件位置的环境变量。
+const ConfigPathEnv = "SYNCLINK_CONFIG"
+
+// configPathFlag 是 --config 标志指定的配置文件路径。
+var configPathFlag string
+
+// SetConfigPath 记录 --config 标志指定的配置文件路径。它优先于 SYNCLINK_CONFIG 环境变量和默认位置。
+func SetConfigPath(p string) {
+	configPathFlag = p
+}
+
+// GetConfigPath 返回配置文件的绝对路径，依次使用 --config 标志、SYNCLINK_CONFIG 环境变量和
+// 默认位置 (见 DefaultConfigPath)。explicit 表示路径是否由用户显式指定。
+// 指定的路径可以包含环境变量令牌；指向已存在的目录时使用其中的 config.json。
+func GetConfigPath() (path string, explicit bool, err error) {
+	p := configPathFlag
+	if p == "" {
+		p = os.Getenv(ConfigPathEnv)
+	}
+	if p == "" {
+		path, err := DefaultConfigPath()
+		return path, false, err
+	}
+
+	path, err = GetAbsPath(ExpandPath(p))
+	if err != nil {
+		return "", true, err
+	}
+	if isDir, _ := IsDir(path); isDir {
+		path = filepath.Join(path, ConfigFileName)
+	}
+	return path, true, nil
+}
+
+// DefaultConfigPath 返回按平台惯例的配置文件位置：Windows 上为 %APPDATA%\synclink\config.json，
+// Linux 上为 $XDG_CONFIG_HOME/synclink/config.json (默认 ~/.config/synclink)，
+// macOS 上为 ~/Library/Application Support/synclink/config.json。
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("确定用户配置目录失败: %w", err)
+	}
+	return filepath.Join(dir, "synclink", ConfigFileName), nil
+}
+
+// LegacyConfigPath 返回旧版本使用的配置文件位置 (可执行文件同目录下)。
+func LegacyConfigPath() (string, error) {
 	exeDir, err := GetExecutableDir()
 	if err != nil {
 		return "", err // 错误已经被包装
@@ -116,52 +159,158 @@ func EnsureDirExists(dirPath string) error {
 	return nil
 }
 
+// WriteFileAtomic 将 data 写入 path：先写入同一目录下的临时文件并刷新到磁盘，再重命名覆盖 path。
+// 写入过程中崩溃、断电或磁盘已满时，path 要么保持原来的内容，要么是完整的新内容。
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// 任一步骤失败时删除临时文件，不留下半成品
+	fail := func(what string, err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("%s '%s' 失败: %w", what, tmpPath, err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return fail("写入临时文件", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail("刷新临时文件", err)
+	}
+	if err := tmp.Chmod(perm); err != nil && runtime.GOOS != "windows" {
+		return fail("设置临时文件权限", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("关闭临时文件 '%s' 失败: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("将临时文件重命名为 '%s' 失败: %w", path, err)
+	}
+	return nil
+}
+
+// Progress 描述一次复制 (或跨设备移动) 的进度。
+type Progress struct {
+	BytesDone  int64  // 已复制的字节数
+	BytesTotal int64  // 需要复制的总字节数
+	FilesDone  int    // 已复制完成的文件数
+	FilesTotal int    // 需要复制的文件总数
+	Current    string // 正在复制的文件
+	Verifying  bool   // 为 true 表示正在校验复制结果，而不是复制
+}
+
+// ProgressFunc 在复制过程中被反复调用以报告进度 (每写入一块数据或完成一个文件调用一次)。
+// 调用频率可能很高，显示进度时应自行节流。
+type ProgressFunc func(Progress)
+
+// progressTracker 累计进度并转发给 ProgressFunc。fn 为 nil 时不做任何事。
+type progressTracker struct {
+	p  Progress
+	fn ProgressFunc
+}
+
+func (t *progressTracker) report() {
+	if t != nil && t.fn != nil {
+		t.fn(t.p)
+	}
+}
+
+// progressWriter 在写入数据时更新进度。
+type progressWriter struct {
+	w io.Writer
+	t *progressTracker
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	if pw.t != nil {
+		pw.t.p.BytesDone += int64(n)
+		pw.t.report()
+	}
+	return n, err
+}
+
+// verifyCopies 决定跨设备移动时是否在删除源数据之前校验副本 (对应设置项 verify_copy)。
+var verifyCopies = true
+
+// SetVerifyCopies 开启或关闭跨设备移动后的校验。
+func SetVerifyCopies(enabled bool) {
+	verifyCopies = enabled
+}
+
 // MoveFileOrDir 移动文件或目录。
 // 它会尝试使用 os.Rename，如果失败（特别是跨设备链接错误），
 // 则会回退到复制然后删除源文件/目录的方式。
-// 注意：跨磁盘移动的进度条需要更复杂的实现（例如使用 io.Copy 和回调），
-// 这个基础版本暂不包含进度条。
+// 复制完成后会用 SHA-256 逐个校验所有文件 (除非通过 SetVerifyCopies 关闭)，只有全部一致才删除源数据；
+// 校验失败时删除副本、保留源数据，并返回列出不一致文件的 *VerifyError。
 func MoveFileOrDir(src, dst string) error {
+	return MoveFileOrDirWithProgress(src, dst, nil)
+}
+
+// MoveFileOrDirWithProgress 与 MoveFileOrDir 相同，但在跨设备复制时通过 progress 报告进度。
+// 同一文件系统内的重命名是瞬间完成的，不会调用 progress。progress 可以为 nil。
+func MoveFileOrDirWithProgress(src, dst string, progress ProgressFunc) error {
+	return MoveFileOrDirNotify(src, dst, progress, nil)
+}
+
+// MoveFileOrDirNotify 与 MoveFileOrDirWithProgress 相同。跨设备移动时，完整且已校验的副本重命名为 dst 之后、
+// 删除源数据之前会调用 onCopied (可以为 nil)：调用者可以借此记录源数据已经可以删除，
+// 中断后恢复时就能区分多余的源数据和恰好同名的其他数据。
+func MoveFileOrDirNotify(src, dst string, progress ProgressFunc, onCopied func()) error {
 	// 1. 尝试直接重命名 (在同一文件系统下速度最快)
 	err := os.Rename(src, dst)
 	if err == nil {
 		return nil // 移动成功
 	}
 
-	// 在不同系统上错误类型可能不同
-	isCrossDevice := false
-	// Linux/macOS: syscall.EXDEV
-	// Windows: 检查错误消息或特定的错误码 (可能不直接暴露为 EXDEV)
-	// 这里我们使用一个更通用的检查方式，虽然可能不够完美
-	// 在 Go 1.8+ 中，syscall.EXDEV 对于 Windows 上的 ERROR_NOT_SAME_DEVICE 也会被转换
-	if errors.Is(err, syscall.EXDEV) || errors.Is(err, windows.ERROR_NOT_SAME_DEVICE) { // 检查底层错误
-		isCrossDevice = true
-	}
-
+	// 在不同系统上错误类型不同，具体判断见 crossdevice_*.go
 	// 如果错误不是预期的跨设备错误，则直接返回错误
-	if !isCrossDevice {
+	if !isCrossDeviceError(err) {
 		return fmt.Errorf("移动 '%s' 到 '%s' 失败: %w", src, dst, err)
 	}
 
 	// 3. 如果是跨设备错误，则执行复制和删除操作
-	// (此处可以添加进度条逻辑)
 
 	isDir, err := IsDir(src)
 	if err != nil {
 		return fmt.Errorf("无法确定源路径 '%s' 类型: %w", src, err)
 	}
 
+	// 先复制到临时名称，完整复制后再重命名为 dst (同一目录内的重命名是原子的)。
+	// 这样中途中断时，dst 要么不存在，要么是完整的副本，恢复时可以据此判断进度。
+	partial := PartialCopyPath(dst)
 	if isDir {
 		// 复制目录
-		if err := CopyDir(src, dst); err != nil {
+		if err := CopyDirWithProgress(src, partial, progress); err != nil {
+			_ = os.RemoveAll(partial)
 			return fmt.Errorf("复制目录 '%s' 到 '%s' 失败: %w", src, dst, err)
 		}
 	} else {
 		// 复制文件
-		if err := CopyFile(src, dst); err != nil {
+		if err := CopyFileWithProgress(src, partial, progress); err != nil {
+			_ = os.Remove(partial)
 			return fmt.Errorf("复制文件 '%s' 到 '%s' 失败: %w", src, dst, err)
 		}
 	}
+	// 删除源数据之前校验副本，确认每个文件都完整无误
+	if verifyCopies {
+		if err := VerifyCopy(src, partial, progress); err != nil {
+			_ = os.RemoveAll(partial)
+			return fmt.Errorf("移动 '%s' 到 '%s' 已取消，源数据保持不变: %w", src, dst, err)
+		}
+	}
+	if err := os.Rename(partial, dst); err != nil {
+		_ = os.RemoveAll(partial)
+		return fmt.Errorf("将临时副本 '%s' 重命名为 '%s' 失败: %w", partial, dst, err)
+	}
+	if onCopied != nil {
+		onCopied()
+	}
 
 	// 4. 复制成功后，删除源文件/目录
 	if err := os.RemoveAll(src); err != nil {
@@ -173,10 +322,36 @@ func MoveFileOrDir(src, dst string) error {
 	return nil // 移动成功
 }
 
+// PartialCopyPath 返回跨设备移动到 dst 时使用的临时副本路径。
+// 该路径存在说明复制尚未完成，源数据仍然完整。
+func PartialCopyPath(dst string) string {
+	return dst + ".synclink-partial"
+}
+
 // CopyFile 复制单个文件从 src 到 dst。
 // 它会尝试保留原始文件的权限。如果目标文件已存在，它将被覆盖。
 // 如果目标目录不存在，会尝试创建它。
 func CopyFile(src, dst string) error {
+	return CopyFileWithProgress(src, dst, nil)
+}
+
+// CopyFileWithProgress 与 CopyFile 相同，但通过 progress 报告进度。progress 可以为 nil。
+func CopyFileWithProgress(src, dst string, progress ProgressFunc) error {
+	var t *progressTracker
+	if progress != nil {
+		info, err := os.Stat(src)
+		if err != nil {
+			return fmt.Errorf("无法获取源文件 '%s' 的信息: %w", src, err)
+		}
+		t = &progressTracker{p: Progress{BytesTotal: info.Size(), FilesTotal: 1}, fn: progress}
+	}
+	ml := &metadataLog{}
+	defer ml.flush()
+	return copyFile(src, dst, t, ml)
+}
+
+// copyFile 执行实际的文件复制，并把进度累计到 t (可以为 nil)，未能保留的元数据记录到 ml。
+func copyFile(src, dst string, t *progressTracker, ml *metadataLog) error {
 	// 确保目标目录存在
 	dstDir := filepath.Dir(dst)
 	if err := EnsureDirExists(dstDir); err != nil {
@@ -190,7 +365,7 @@ func CopyFile(src, dst string) error {
 	}
 	defer sourceFile.Close() // 确保文件句柄被关闭
 
-	// 获取源文件信息 (用于权限)
+	// 获取源文件信息 (用于权限、所有者和时间戳)，需在读取内容之前获取以保留原始的访问时间
 	sourceInfo, err := sourceFile.Stat()
 	if err != nil {
 		return fmt.Errorf("无法获取源文件 '%s' 的信息: %w", src, err)
@@ -203,9 +378,13 @@ func CopyFile(src, dst string) error {
 	}
 	defer destFile.Close() // 确保文件句柄被关闭
 
-	// 使用 io.Copy 进行高效复制
-	// (如果需要进度条，需要使用 io.CopyBuffer 和一个自定义的 Reader/Writer)
-	bytesCopied, err := io.Copy(destFile, sourceFile)
+	if t != nil {
+		t.p.Current = src
+		t.report()
+	}
+
+	// 通过 progressWriter 复制，每写入一块数据更新一次进度
+	bytesCopied, err := io.CopyBuffer(&progressWriter{w: destFile, t: t}, sourceFile, make([]byte, 1<<20))
 	if err != nil {
 		return fmt.Errorf("复制文件内容从 '%s' 到 '%s' 失败: %w", src, dst, err)
 	}
@@ -226,14 +405,19 @@ func CopyFile(src, dst string) error {
 		// 不返回错误，因为内容已经复制了
 	}
 
-	// 尝试设置目标文件的权限 (与源文件相同)
-	// 注意：在某些系统或文件系统上，这可能不完全成功或不被支持
-	err = os.Chmod(dst, sourceInfo.Mode())
-	if err != nil {
-		WarningPrint("设置目标文件 '%s' 权限失败: %v", dst, err)
-		// 不返回错误，因为主要复制操作已完成
+	// 先关闭目标文件，再设置元数据，避免关闭时刷新的写入覆盖刚设置的修改时间
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("关闭目标文件 '%s' 失败: %w", dst, err)
 	}
 
+	// 尝试保留所有者、权限、扩展属性和时间戳 (与源文件相同)
+	// 注意：在某些系统或文件系统上，这可能不完全成功或不被支持，失败的项目会记录下来而不返回错误
+	preserveMetadata(src, dst, sourceInfo, ml)
+
+	if t != nil {
+		t.p.FilesDone++
+		t.report()
+	}
 	return nil
 }
 
@@ -241,6 +425,17 @@ func CopyFile(src, dst string) error {
 // 如果目标目录 dst 不存在，它将被创建。
 // 如果目标目录或其中的子项已存在，它们的行为取决于 CopyFile（文件会被覆盖）。
 func CopyDir(src, dst string) error {
+	return CopyDirWithProgress(src, dst, nil)
+}
+
+// CopyDirWithProgress 与 CopyDir 相同，但通过 progress 报告进度。progress 可以为 nil。
+//
+// 除了目录和普通文件之外：符号链接按原样 (保留原始的相对或绝对目标) 重新创建；
+// 目录内互为硬链接的文件只复制一次，其余路径在目标中重新建立硬链接。
+// 复制前会先扫描整个目录，如果其中有无法复制的条目 (设备文件、命名管道、套接字、
+// Windows 目录联接等)，则不复制任何内容并返回列出这些条目的 *UncopyableError，
+// 以免随后删除源目录时丢失它们。
+func CopyDirWithProgress(src, dst string, progress ProgressFunc) error {
 	src = filepath.Clean(src)
 	dst = filepath.Clean(dst)
 
@@ -253,6 +448,20 @@ func CopyDir(src, dst string) error {
 		return fmt.Errorf("源路径 '%s' 不是一个目录", src)
 	}
 
+	// 先扫描：统计进度总量，并在复制任何内容之前找出无法复制的条目
+	bytes, files, uncopyable, dirInfos, err := scanDir(src)
+	if err != nil {
+		return err
+	}
+	if len(uncopyable) > 0 {
+		return &UncopyableError{Dir: src, Entries: uncopyable}
+	}
+	var t *progressTracker
+	if progress != nil {
+		t = &progressTracker{p: Progress{BytesTotal: bytes, FilesTotal: files}, fn: progress}
+		t.report()
+	}
+
 	// 创建目标根目录 (如果不存在)
 	// 使用源目录的权限模式
 	err = os.MkdirAll(dst, srcInfo.Mode())
@@ -260,6 +469,18 @@ func CopyDir(src, dst string) error {
 		return fmt.Errorf("无法创建目标目录 '%s': %w", dst, err)
 	}
 
+	// 已复制的硬链接组：源文件标识 -> 该组第一个文件在目标中的路径
+	copiedLinks := make(map[fileID]string)
+
+	// 目录的元数据要在其中所有内容复制完成后再设置，否则写入子项会再次改变目录的修改时间
+	type copiedDir struct {
+		src, dst string
+		info     fs.FileInfo
+	}
+	dirs := []copiedDir{{src: src, dst: dst, info: srcInfo}}
+	ml := &metadataLog{}
+	defer ml.flush()
+
 	// 使用 WalkDir 遍历源目录
 	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		// 1. 处理 WalkDir 本身遇到的错误 (例如权限问题)
@@ -282,25 +503,56 @@ func CopyDir(src, dst string) error {
 		}
 
 		// 4. 根据类型处理
-		if d.IsDir() {
+		switch {
+		case d.IsDir():
 			// 如果是目录，则在目标位置创建它
-			// 获取原始目录的权限
-			info, dirErr := d.Info()
-			if dirErr != nil {
-				return fmt.Errorf("无法获取目录 '%s' 的信息: %w", path, dirErr)
+			// 使用扫描时获取的信息：扫描已经读取过目录内容，此时再获取的访问时间已被更新
+			info, ok := dirInfos[path]
+			if !ok {
+				return fmt.Errorf("无法获取目录 '%s' 的信息", path)
 			}
 			if err := os.MkdirAll(targetPath, info.Mode()); err != nil {
 				return fmt.Errorf("无法在目标位置创建目录 '%s': %w", targetPath, err)
 			}
-		} else if d.Type().IsRegular() { // 确保是普通文件 (跳过符号链接等)
-			// 如果是文件，则复制它
-			if err := CopyFile(path, targetPath); err != nil {
-				// 错误已经被包装在 CopyFile 内部了
+			dirs = append(dirs, copiedDir{src: path, dst: targetPath, info: info})
+		case d.Type()&fs.ModeSymlink != 0:
+			// 符号链接：按原样重新创建，不跟随
+			linkTarget, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("无法读取符号链接 '%s' 的目标: %w", path, err)
+			}
+			if err := os.Symlink(linkTarget, targetPath); err != nil {
+				return fmt.Errorf("无法在目标位置创建符号链接 '%s' -> '%s': %w", targetPath, linkTarget, err)
+			}
+			if info, err := d.Info(); err == nil {
+				preserveMetadata(path, targetPath, info, ml)
+			}
+		case d.Type().IsRegular():
+			id, links, err := hardlinkID(path)
+			if err != nil {
+				return err
+			}
+			if links > 1 {
+				if first, ok := copiedLinks[id]; ok {
+					// 同一硬链接组中已复制过的文件：在目标中建立硬链接，而不是再复制一份
+					if err := os.Link(first, targetPath); err != nil {
+						return fmt.Errorf("无法在目标位置创建硬链接 '%s' -> '%s': %w", targetPath, first, err)
+					}
+					if t != nil {
+						if info, err := d.Info(); err == nil {
+							t.p.BytesDone += info.Size()
+						}
+						t.p.FilesDone++
+						t.report()
+					}
+					return nil
+				}
+				copiedLinks[id] = targetPath
+			}
+			if err := copyFile(path, targetPath, t, ml); err != nil {
+				// 错误已经被包装在 copyFile 内部了
 				return err // 直接返回错误，停止 Walk
 			}
-		} else {
-			// 可以选择性地处理符号链接、设备文件等，或直接跳过
-			fmt.Printf("跳过非普通文件/目录: %s (类型: %s)\n", path, d.Type())
 		}
 
 		return nil // 继续遍历
@@ -311,9 +563,142 @@ func CopyDir(src, dst string) error {
 		return fmt.Errorf("复制目录 '%s' 到 '%s' 过程中失败: %w", src, dst, err)
 	}
 
+	// 从最深的目录开始设置目录的元数据
+	for i := len(dirs) - 1; i >= 0; i-- {
+		preserveMetadata(dirs[i].src, dirs[i].dst, dirs[i].info, ml)
+	}
 	return nil
 }
 
+// preserveMetadata 将 src 的所有者、权限、扩展属性和访问/修改时间应用到 dst。
+// info 是复制前获取的 src 的信息 (符号链接为 Lstat 的结果)。
+// 符号链接只保留所有者和扩展属性：权限和时间戳的设置会跟随链接作用到目标上。
+// 无法保留的项目记录到 ml，不会中止复制。
+func preserveMetadata(src, dst string, info fs.FileInfo, ml *metadataLog) {
+	isLink := info.Mode()&fs.ModeSymlink != 0
+
+	// 先设置所有者：更改所有者可能会清除 setuid/setgid 位，随后的 Chmod 会恢复它们
+	if err := copyOwner(dst, info); err != nil {
+		ml.add(dst, "所有者", err)
+	}
+	if !isLink {
+		if err := os.Chmod(dst, info.Mode()); err != nil {
+			ml.add(dst, "权限", err)
+		}
+	}
+	if err := copyXattrs(src, dst); err != nil {
+		ml.add(dst, "扩展属性", err)
+	}
+	if !isLink {
+		atime, ok := fileAtime(info)
+		if !ok {
+			atime = info.ModTime()
+		}
+		if err := os.Chtimes(dst, atime, info.ModTime()); err != nil {
+			ml.add(dst, "时间戳", err)
+		}
+	}
+}
+
+// metadataLog 收集复制过程中未能保留的元数据，复制结束后统一打印警告。
+type metadataLog struct {
+	notes []string
+}
+
+func (l *metadataLog) add(path, what string, err error) {
+	l.notes = append(l.notes, fmt.Sprintf("%s: %s (%v)", path, what, err))
+}
+
+// flush 打印收集到的警告。
+func (l *metadataLog) flush() {
+	if len(l.notes) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n有 %d 项元数据未能保留:", len(l.notes))
+	for i, note := range l.notes {
+		if i == maxReportedMismatches {
+			fmt.Fprintf(&b, "\n  ... 以及另外 %d 项", len(l.notes)-maxReportedMismatches)
+			break
+		}
+		fmt.Fprintf(&b, "\n  %s", note)
+	}
+	WarningPrint("%s\n", b.String())
+	l.notes = nil
+}
+
+// UncopyableError 表示目录中存在 CopyDir 无法复制的条目，因此拒绝复制。
+type UncopyableError struct {
+	Dir     string
+	Entries []string // 每一项为 "相对路径 (类型)"
+}
+
+func (e *UncopyableError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "目录 '%s' 中有 %d 个无法复制的条目，为防止数据丢失已拒绝复制，请先手动处理:", e.Dir, len(e.Entries))
+	for _, entry := range e.Entries {
+		fmt.Fprintf(&b, "\n  %s", entry)
+	}
+	return b.String()
+}
+
+// DirSize 统计目录 dir 下所有普通文件的总字节数和文件数 (与 CopyDir 复制的范围一致)。
+func DirSize(dir string) (bytes int64, files int, err error) {
+	bytes, files, _, _, err = scanDir(dir)
+	return bytes, files, err
+}
+
+// scanDir 遍历目录 dir，统计普通文件的总字节数和文件数，并列出 CopyDir 无法复制的条目。
+// 目录、普通文件和符号链接都可以复制。同时返回每个目录在被读取之前的信息 (用于保留访问时间)。
+func scanDir(dir string) (bytes int64, files int, uncopyable []string, dirInfos map[string]fs.FileInfo, err error) {
+	dirInfos = make(map[string]fs.FileInfo)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("遍历 '%s' 时出错: %w", path, err)
+		}
+		switch {
+		case d.IsDir():
+			info, err := d.Info()
+			if err != nil {
+				return fmt.Errorf("无法获取目录 '%s' 的信息: %w", path, err)
+			}
+			dirInfos[path] = info
+		case d.Type()&fs.ModeSymlink != 0:
+			return nil
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return fmt.Errorf("无法获取文件 '%s' 的信息: %w", path, err)
+			}
+			bytes += info.Size()
+			files++
+		default:
+			rel, _ := filepath.Rel(dir, path)
+			uncopyable = append(uncopyable, fmt.Sprintf("%s (%s)", rel, describeFileType(d.Type())))
+		}
+		return nil
+	})
+	return bytes, files, uncopyable, dirInfos, err
+}
+
+// describeFileType 返回无法复制的条目类型的描述。
+func describeFileType(m fs.FileMode) string {
+	switch {
+	case m&fs.ModeNamedPipe != 0:
+		return "命名管道"
+	case m&fs.ModeSocket != 0:
+		return "套接字"
+	case m&fs.ModeCharDevice != 0:
+		return "字符设备"
+	case m&fs.ModeDevice != 0:
+		return "设备文件"
+	case m&fs.ModeIrregular != 0:
+		return "特殊条目，例如目录联接或重解析点"
+	default:
+		return m.Type().String()
+	}
+}
+
 // GetAbsPath 获取绝对路径，如果已经是绝对路径则直接返回，否则相对于 PWD 解析。
 func GetAbsPath(p string) (string, error) {
 	if filepath.IsAbs(p) {
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxReportedMismatches 是 VerifyError 的错误信息中最多列出的不一致文件数量。
+const maxReportedMismatches = 20
+
+// Mismatch 描述校验时发现的一个不一致的文件。
+type Mismatch struct {
+	Path   string // 相对于源目录的路径 (源是单个文件时为文件名)
+	Reason string
+}
+
+// VerifyError 表示复制后的校验失败，列出所有不一致的文件。
+type VerifyError struct {
+	Src, Dst   string
+	Mismatches []Mismatch
+}
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "校验 '%s' 与 '%s' 失败，%d 个文件不一致:", e.Src, e.Dst, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		if i == maxReportedMismatches {
+			fmt.Fprintf(&b, "\n  ... 以及另外 %d 个文件", len(e.Mismatches)-maxReportedMismatches)
+			break
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", m.Path, m.Reason)
+	}
+	return b.String()
+}
+
+// VerifyCopy 逐个比较 src 中每个普通文件 (与 CopyDir 复制的范围一致) 与 dst 中对应文件的
+// 大小和 SHA-256 校验和，并确认每个符号链接都以相同的目标重新创建。
+// 全部一致时返回 nil；存在不一致时返回 *VerifyError。
+// progress 可以为 nil，报告时 Progress.Verifying 为 true。
+func VerifyCopy(src, dst string, progress ProgressFunc) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("无法获取源路径 '%s' 的信息: %w", src, err)
+	}
+
+	var t *progressTracker
+	if progress != nil {
+		t = &progressTracker{p: Progress{Verifying: true}, fn: progress}
+		if srcInfo.IsDir() {
+			if t.p.BytesTotal, t.p.FilesTotal, err = DirSize(src); err != nil {
+				return err
+			}
+		} else {
+			t.p.BytesTotal, t.p.FilesTotal = srcInfo.Size(), 1
+		}
+		t.report()
+	}
+
+	verr := &VerifyError{Src: src, Dst: dst}
+	if !srcInfo.IsDir() {
+		if reason, err := compareFiles(src, dst, t); err != nil {
+			return err
+		} else if reason != "" {
+			verr.Mismatches = append(verr.Mismatches, Mismatch{Path: filepath.Base(src), Reason: reason})
+		}
+	} else {
+		err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("遍历 '%s' 时出错: %w", path, err)
+			}
+			isLink := d.Type()&fs.ModeSymlink != 0
+			if !isLink && !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(src, path)
+			if err != nil {
+				return fmt.Errorf("无法计算相对路径 '%s' from '%s': %w", path, src, err)
+			}
+			var reason string
+			if isLink {
+				reason, err = compareSymlinks(path, filepath.Join(dst, rel))
+			} else {
+				reason, err = compareFiles(path, filepath.Join(dst, rel), t)
+			}
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				verr.Mismatches = append(verr.Mismatches, Mismatch{Path: rel, Reason: reason})
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("校验 '%s' 与 '%s' 过程中失败: %w", src, dst, err)
+		}
+	}
+
+	if len(verr.Mismatches) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// compareFiles 比较两个文件，一致时返回空字符串，否则返回不一致的原因。
+// 只有读取源文件失败才返回错误；目标文件缺失或无法读取都视为不一致。
+func compareFiles(src, dst string, t *progressTracker) (string, error) {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return "", fmt.Errorf("无法获取源文件 '%s' 的信息: %w", src, err)
+	}
+	if t != nil {
+		t.p.Current = src
+	}
+	defer func() {
+		if t != nil {
+			t.p.FilesDone++
+			t.report()
+		}
+	}()
+
+	dstInfo, err := os.Stat(dst)
+	if err != nil {
+		if t != nil {
+			t.p.BytesDone += srcInfo.Size()
+		}
+		if os.IsNotExist(err) {
+			return "目标文件不存在", nil
+		}
+		return fmt.Sprintf("无法读取目标文件: %v", err), nil
+	}
+	if dstInfo.Size() != srcInfo.Size() {
+		if t != nil {
+			t.p.BytesDone += srcInfo.Size()
+		}
+		return fmt.Sprintf("大小不一致 (源 %d 字节，目标 %d 字节)", srcInfo.Size(), dstInfo.Size()), nil
+	}
+
+	srcSum, err := fileSHA256(src, t)
+	if err != nil {
+		return "", err
+	}
+	dstSum, err := fileSHA256(dst, nil)
+	if err != nil {
+		return fmt.Sprintf("无法读取目标文件: %v", err), nil
+	}
+	// 校验只是读取目标文件，不应改变复制时保留下来的访问时间
+	if atime, ok := fileAtime(dstInfo); ok {
+		_ = os.Chtimes(dst, atime, dstInfo.ModTime())
+	}
+	if srcSum != dstSum {
+		return fmt.Sprintf("SHA-256 不一致 (源 %s，目标 %s)", srcSum, dstSum), nil
+	}
+	return "", nil
+}
+
+// compareSymlinks 比较两个符号链接的目标，一致时返回空字符串，否则返回不一致的原因。
+func compareSymlinks(src, dst string) (string, error) {
+	want, err := os.Readlink(src)
+	if err != nil {
+		return "", fmt.Errorf("无法读取符号链接 '%s' 的目标: %w", src, err)
+	}
+	got, err := os.Readlink(dst)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "目标中缺少符号链接", nil
+		}
+		return fmt.Sprintf("目标不是符号链接或无法读取: %v", err), nil
+	}
+	if got != want {
+		return fmt.Sprintf("符号链接目标不一致 (源 '%s'，目标 '%s')", want, got), nil
+	}
+	return "", nil
+}
+
+// fileSHA256 计算文件的 SHA-256 校验和 (十六进制)，读取的字节计入 t 的进度。
+func fileSHA256(path string, t *progressTracker) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("无法打开文件 '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.CopyBuffer(&progressWriter{w: h, t: t}, f, make([]byte, 1<<20)); err != nil {
+		return "", fmt.Errorf("读取文件 '%s' 失败: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}